@@ -0,0 +1,126 @@
+// Copyright (c) 2017. Oleg Sklyar & teris.io. All rights reserved.
+// See the LICENSE file in the project root for licensing information.
+
+package gitignore_test
+
+import (
+	"github.com/teris-io/gitignore"
+	"strings"
+	"testing"
+)
+
+// foreignPattern is a pattern implemented outside of the package.
+type foreignPattern struct {
+	gitignore.Pattern
+}
+
+func allocMatchers(patterns []gitignore.Pattern) map[string]gitignore.Matcher {
+	layered := gitignore.NewLayeredMatcher(gitignore.MatcherOptions{})
+	layered.SetLayer(gitignore.GitignoreLayer, patterns)
+	tree := gitignore.NewTreeMatcher(func(dir []string) ([]gitignore.Pattern, error) {
+		if len(dir) == 0 {
+			// all patterns come from the root, the domains tell where they apply
+			return patterns, nil
+		}
+		return nil, nil
+	})
+	return map[string]gitignore.Matcher{
+		"linear":   gitignore.NewMatcher(patterns),
+		"lenient":  gitignore.NewMatcherWithOptions(patterns, gitignore.MatcherOptions{Lenient: true}),
+		"compiled": gitignore.NewCompiledMatcher(patterns),
+		"layered":  layered,
+		"tree":     tree,
+	}
+}
+
+func TestMatcher_MatchString(t *testing.T) {
+	patterns, paths := compiledCases(11)
+	for name, m := range allocMatchers(patterns) {
+		for _, path := range paths {
+			s := strings.Join(path, "/")
+			for _, isDir := range []bool{false, true} {
+				expected := m.Match(path, isDir)
+				if found := m.MatchString(s, isDir); found != expected {
+					t.Errorf("%s %s: expected %v, found %v", name, s, expected, found)
+				}
+				if found := m.MatchBytes([]byte("/"+s+"//"), isDir); found != expected {
+					t.Errorf("%s %s: expected %v for bytes, found %v", name, s, expected, found)
+				}
+			}
+		}
+	}
+}
+
+func TestMatcher_MatchStringAllocs(t *testing.T) {
+	paths := []string{"a/b/x/keep.log", "src/docs/doc.pdf", "node_modules/tmpfile", "a/b/c/d/e/f/g/h/i/j/k/main.go"}
+	bpath := []byte("docs/x/y/pkg.tar.gz")
+	patterns, _ := compiledCases(11)
+	for name, m := range allocMatchers(patterns) {
+		// loads the patterns of the tree matcher
+		for _, path := range paths {
+			m.MatchString(path, false)
+		}
+		m.MatchBytes(bpath, false)
+		allocs := testing.AllocsPerRun(100, func() {
+			for _, path := range paths {
+				m.MatchString(path, false)
+				m.MatchString(path, true)
+			}
+			m.MatchBytes(bpath, false)
+		})
+		if allocs != 0 {
+			t.Errorf("%s: expected no allocations, found %v", name, allocs)
+		}
+	}
+}
+
+func TestMatcher_foreignPatternAllocs(t *testing.T) {
+	patterns, paths := compiledCases(11)
+	foreign := make([]gitignore.Pattern, len(patterns))
+	for i, p := range patterns {
+		foreign[i] = foreignPattern{p}
+	}
+	path := []string{"a", "b", "x", "keep.log"}
+	s := strings.Join(path, "/")
+	for name, m := range allocMatchers(foreign) {
+		for _, path := range paths {
+			if expected, found := m.Match(path, false), m.MatchString(strings.Join(path, "/"), false); found != expected {
+				t.Errorf("%s %v: expected %v, found %v", name, path, expected, found)
+			}
+		}
+		if allocs := testing.AllocsPerRun(100, func() { m.Match(path, false) }); allocs != 0 {
+			t.Errorf("%s: expected no allocations, found %v", name, allocs)
+		}
+		if allocs := testing.AllocsPerRun(100, func() { m.MatchString(s, false) }); allocs != 1 {
+			t.Errorf("%s: expected a single copy of the path, found %v allocations", name, allocs)
+		}
+	}
+}
+
+func TestPattern_MatchAllocs(t *testing.T) {
+	patterns, paths := compiledCases(5)
+	allocs := testing.AllocsPerRun(10, func() {
+		for _, p := range patterns {
+			for _, path := range paths[:50] {
+				p.Match(path, false)
+			}
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocations, found %v", allocs)
+	}
+}
+
+func BenchmarkMatcher_MatchString(b *testing.B) {
+	patterns, paths := benchmarkCases()
+	strs := make([]string, len(paths))
+	for i, path := range paths {
+		strs[i] = strings.Join(path, "/")
+	}
+	m := gitignore.NewCompiledMatcher(patterns)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.MatchString(strs[i%len(strs)], false)
+	}
+}
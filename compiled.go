@@ -0,0 +1,231 @@
+// Copyright (c) 2017. Oleg Sklyar & teris.io. All rights reserved.
+// See the LICENSE file in the project root for licensing information.
+
+package gitignore
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// NewCompiledMatcher constructs a matcher behaving exactly as NewMatcher does, but
+// indexing the patterns up front so that matching a path only evaluates the few
+// patterns that can possibly match it. Patterns are indexed by their domain and by the
+// literal name, extension or prefix their last segment matches. It pays off for large
+// sets of patterns matched against many paths.
+func NewCompiledMatcher(patterns []Pattern) Matcher {
+	return NewCompiledMatcherWithOptions(patterns, MatcherOptions{})
+}
+
+// NewCompiledMatcherWithOptions constructs a compiled matcher with the given options, see
+// NewCompiledMatcher.
+func NewCompiledMatcherWithOptions(patterns []Pattern, options MatcherOptions) Matcher {
+	m := &compiledMatcher{patterns: patterns, options: options, root: &domainNode{}}
+	for i, pattern := range patterns {
+		p, ok := pattern.(*ptrn)
+		m.foreign = m.foreign || !ok
+		if !ok || p.fold && !options.CaseInsensitive {
+			// the domain of a case-insensitive pattern is not matched exactly either
+			m.root.other = append(m.root.other, i)
+			continue
+		}
+		node := m.root
+		for _, name := range p.domain {
+			node = node.child(m.key(name))
+		}
+		node.add(i, p, m.key)
+	}
+	return m
+}
+
+type compiledMatcher struct {
+	patterns []Pattern
+	options  MatcherOptions
+	root     *domainNode
+	// foreign tells whether any of the patterns is implemented outside of this package
+	foreign bool
+}
+
+// domainNode indexes the patterns of a single domain, its children are keyed by the
+// next path component.
+type domainNode struct {
+	children   map[string]*domainNode
+	names      map[string][]int
+	exts       map[string][]int
+	prefixes   map[string][]int
+	prefixLens []int
+	// other lists the patterns evaluated for every path
+	other []int
+}
+
+func (m *compiledMatcher) Match(path []string, isDir bool) bool {
+	return m.Result(path, isDir) == Exclude
+}
+
+func (m *compiledMatcher) MatchPath(path string, isDir bool) (bool, error) {
+	return matchPath(m, path, isDir)
+}
+
+func (m *compiledMatcher) MatchFile(root, path string) (bool, error) {
+	return matchFile(m, root, path)
+}
+
+func (m *compiledMatcher) MatchString(path string, isDir bool) bool {
+	var buf [maxStackDepth]string
+	split := splitString(buf[:0], path)
+	return m.explain(split, heapPath(split, m.foreign), isDir).Result == Exclude
+}
+
+func (m *compiledMatcher) MatchBytes(path []byte, isDir bool) bool {
+	return m.MatchString(bytesToString(path), isDir)
+}
+
+func (m *compiledMatcher) Result(path []string, isDir bool) MatchResult {
+	return m.Explain(path, isDir).Result
+}
+
+func (m *compiledMatcher) Explain(path []string, isDir bool) Explanation {
+	return m.explain(path, path, isDir)
+}
+
+// explain matches the path as Explain does, see matcher.explain.
+func (m *compiledMatcher) explain(path, heap []string, isDir bool) Explanation {
+	var flags matchFlags
+	if m.options.CaseInsensitive {
+		flags |= matchFold
+	}
+	if m.options.Lenient {
+		// a pattern matching a parent directory matches the path as well
+		j, match := -1, NoMatch
+		for i := 1; i <= len(path); i++ {
+			j, match = m.lookup(path[:i], heap, i < len(path) || isDir, flags, j, match)
+		}
+		if match > NoMatch {
+			return explain(m.patterns[j], match, nil)
+		}
+		return Explanation{}
+	}
+	for i := 1; i < len(path); i++ {
+		if j, match := m.lookup(path[:i], heap, true, flags, -1, NoMatch); match == Exclude {
+			return explain(m.patterns[j], match, path[:i])
+		}
+	}
+	if j, match := m.lookup(path, heap, isDir, flags, -1, NoMatch); match > NoMatch {
+		return explain(m.patterns[j], match, nil)
+	}
+	return Explanation{}
+}
+
+// lookup matches the path itself against the patterns that may match it and returns the
+// index and the outcome of the last one matching, as matchLast does. Only patterns after
+// the given index are considered, otherwise the index and outcome given are returned.
+func (m *compiledMatcher) lookup(path, heap []string, isDir bool, flags matchFlags, best int, result MatchResult) (int, MatchResult) {
+	if len(path) == 0 {
+		return best, result
+	}
+	name := m.key(path[len(path)-1])
+	eval := func(indices []int) {
+		for k := len(indices) - 1; k >= 0 && indices[k] > best; k-- {
+			if match := matchPattern(m.patterns[indices[k]], path, heap, isDir, flags|matchExact); match > NoMatch {
+				best, result = indices[k], match
+				return
+			}
+		}
+	}
+	node := m.root
+	for depth := 0; node != nil && depth < len(path); depth++ {
+		eval(node.other)
+		eval(node.names[name])
+		for i := 0; i < len(name) && node.exts != nil; i++ {
+			if name[i] == '.' {
+				eval(node.exts[name[i+1:]])
+			}
+		}
+		for _, n := range node.prefixLens {
+			if n > len(name) {
+				break
+			}
+			eval(node.prefixes[name[:n]])
+		}
+		node = node.children[m.key(path[depth])]
+	}
+	return best, result
+}
+
+// key returns the index key of a name, which is folded for case-insensitive matching.
+func (m *compiledMatcher) key(name string) string {
+	if m.options.CaseInsensitive {
+		return foldKey(name)
+	}
+	return name
+}
+
+func (n *domainNode) child(key string) *domainNode {
+	if n.children == nil {
+		n.children = make(map[string]*domainNode)
+	}
+	c, ok := n.children[key]
+	if !ok {
+		c = &domainNode{}
+		n.children[key] = c
+	}
+	return c
+}
+
+// add indexes the pattern by its last segment, the one matching the name of the path.
+func (n *domainNode) add(i int, p *ptrn, key func(string) string) {
+	segment := ""
+	for _, s := range p.pattern {
+		if s != "" {
+			segment = s
+		}
+	}
+	if name, ok := literalName(segment); ok {
+		n.names = addIndex(n.names, key(name), i)
+	} else if ext, ok := literalName(strings.TrimPrefix(segment, "*")); ok && strings.HasPrefix(segment, "*.") && len(ext) > 1 {
+		n.exts = addIndex(n.exts, key(ext[1:]), i)
+	} else if prefix, ok := literalName(strings.TrimSuffix(segment, "*")); ok && strings.HasSuffix(segment, "*") {
+		prefix = key(prefix)
+		if _, seen := n.prefixes[prefix]; !seen {
+			n.prefixLens = append(n.prefixLens, len(prefix))
+			sort.Ints(n.prefixLens)
+			n.prefixLens = uniqueInts(n.prefixLens)
+		}
+		n.prefixes = addIndex(n.prefixes, prefix, i)
+	} else {
+		n.other = append(n.other, i)
+	}
+}
+
+func addIndex(index map[string][]int, key string, i int) map[string][]int {
+	if index == nil {
+		index = make(map[string][]int)
+	}
+	index[key] = append(index[key], i)
+	return index
+}
+
+func uniqueInts(sorted []int) []int {
+	out := sorted[:0]
+	for i, v := range sorted {
+		if i == 0 || v != sorted[i-1] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// foldKey maps every rune of the name to the smallest rune of its simple case folding
+// orbit, so that names equal under simple case folding have the same key.
+func foldKey(name string) string {
+	return strings.Map(func(r rune) rune {
+		min := r
+		for c := unicode.SimpleFold(r); c != r; c = unicode.SimpleFold(c) {
+			if c < min {
+				min = c
+			}
+		}
+		return min
+	}, name)
+}
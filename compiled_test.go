@@ -0,0 +1,127 @@
+// Copyright (c) 2017. Oleg Sklyar & teris.io. All rights reserved.
+// See the LICENSE file in the project root for licensing information.
+
+package gitignore_test
+
+import (
+	"fmt"
+	"github.com/teris-io/gitignore"
+	"math/rand"
+	"testing"
+)
+
+var compiledPatterns = []string{
+	"*.log", "!keep.log", "build/", "/dist", "node_modules", "tmp*", "!tmp.keep",
+	"docs/**/*.pdf", "a/**", "**/cache/", "v[ou]l?ano", "*.tar.gz", "!/a/b/",
+	"src/*.o", "\\*star", "Makefile", "*", "!*.go", "*~", "**/x/**/y",
+}
+
+var compiledNames = []string{
+	"a", "b", "x", "y", "src", "docs", "build", "dist", "node_modules", "cache",
+	"main.go", "debug.log", "keep.log", "tmp", "tmp.keep", "tmpfile", "doc.pdf",
+	"volcano", "vulkano", "pkg.tar.gz", "gz", "*star", "Makefile", "MAKEFILE", "file~",
+	"Debug.LOG", "Keep.log",
+}
+
+// compiledCases generates patterns from several domains and paths among them.
+func compiledCases(seed int64) ([]gitignore.Pattern, [][]string) {
+	rnd := rand.New(rand.NewSource(seed))
+	domains := [][]string{nil, {"a"}, {"a", "b"}, {"src"}, {"docs", "x"}}
+	var patterns []gitignore.Pattern
+	for i := 0; i < 60; i++ {
+		domain := domains[rnd.Intn(len(domains))]
+		patterns = append(patterns, gitignore.ParsePattern(compiledPatterns[rnd.Intn(len(compiledPatterns))], domain))
+	}
+	var paths [][]string
+	for i := 0; i < 2000; i++ {
+		path := make([]string, 1+rnd.Intn(5))
+		for j := range path {
+			path[j] = compiledNames[rnd.Intn(len(compiledNames))]
+		}
+		paths = append(paths, path)
+	}
+	return patterns, paths
+}
+
+func TestCompiledMatcher(t *testing.T) {
+	for _, options := range []gitignore.MatcherOptions{{}, {Lenient: true}, {CaseInsensitive: true}, {Lenient: true, CaseInsensitive: true}} {
+		for seed := int64(0); seed < 20; seed++ {
+			patterns, paths := compiledCases(seed)
+			linear := gitignore.NewMatcherWithOptions(patterns, options)
+			compiled := gitignore.NewCompiledMatcherWithOptions(patterns, options)
+			for _, path := range paths {
+				for _, isDir := range []bool{false, true} {
+					expected, found := linear.Explain(path, isDir), compiled.Explain(path, isDir)
+					if expected.Result != found.Result || expected.Pattern != found.Pattern || fmt.Sprint(expected.Parent) != fmt.Sprint(found.Parent) {
+						t.Fatalf("%+v seed %d, %v %v: expected %+v, found %+v", options, seed, path, isDir, expected, found)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestCompiledMatcher_Match(t *testing.T) {
+	m := gitignore.NewCompiledMatcher([]gitignore.Pattern{
+		gitignore.ParsePattern("*.log", nil),
+		gitignore.ParsePattern("!keep.log", nil),
+		gitignore.ParsePatternWithOptions("BUILD/", nil, gitignore.ParseOptions{CaseInsensitive: true}),
+	})
+	if !m.Match([]string{"a", "debug.log"}, false) {
+		t.Error("expected a/debug.log to be excluded")
+	}
+	if res := m.Result([]string{"a", "keep.log"}, false); res != gitignore.Include {
+		t.Errorf("expected Include, found %v", res)
+	}
+	if !m.Match([]string{"x", "build", "main.go"}, false) {
+		t.Error("expected x/build/main.go to be excluded")
+	}
+}
+
+// benchmarkCases generates a large rule set in the style of a monorepo along with the
+// paths to match.
+func benchmarkCases() ([]gitignore.Pattern, [][]string) {
+	rnd := rand.New(rand.NewSource(1))
+	var patterns []gitignore.Pattern
+	for i := 0; i < 2000; i++ {
+		domain := []string{"services", fmt.Sprintf("svc%d", i%100)}
+		var text string
+		switch i % 5 {
+		case 0:
+			text = fmt.Sprintf("generated%d.go", i)
+		case 1:
+			text = fmt.Sprintf("*.ext%d", i)
+		case 2:
+			text = fmt.Sprintf("cache%d*", i)
+		case 3:
+			text = fmt.Sprintf("/out%d/", i)
+		case 4:
+			text = fmt.Sprintf("!keep%d.txt", i)
+		}
+		patterns = append(patterns, gitignore.ParsePattern(text, domain))
+	}
+	var paths [][]string
+	for i := 0; i < 1000; i++ {
+		svc := fmt.Sprintf("svc%d", rnd.Intn(100))
+		paths = append(paths, []string{"services", svc, "pkg", fmt.Sprintf("file%d.ext%d", i, rnd.Intn(2000))})
+	}
+	return patterns, paths
+}
+
+func benchmarkMatcher(b *testing.B, m gitignore.Matcher, paths [][]string) {
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Match(paths[i%len(paths)], false)
+	}
+}
+
+func BenchmarkMatcher_linear(b *testing.B) {
+	patterns, paths := benchmarkCases()
+	benchmarkMatcher(b, gitignore.NewMatcher(patterns), paths)
+}
+
+func BenchmarkMatcher_compiled(b *testing.B) {
+	patterns, paths := benchmarkCases()
+	benchmarkMatcher(b, gitignore.NewCompiledMatcher(patterns), paths)
+}
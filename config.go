@@ -0,0 +1,410 @@
+// Copyright (c) 2017. Oleg Sklyar & teris.io. All rights reserved.
+// See the LICENSE file in the project root for licensing information.
+
+package gitignore
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Config holds the git configuration settings that affect ignore matching.
+type Config struct {
+	// ExcludesFile is the value of core.excludesFile, empty if not set
+	ExcludesFile string
+	// IgnoreCase is the value of core.ignoreCase
+	IgnoreCase bool
+}
+
+// maxIncludeDepth limits nested includes as git does to catch include cycles.
+const maxIncludeDepth = 10
+
+// ReadConfig reads the git configuration of the repository with the given git directory
+// in the order of increasing precedence: the system file, the global files, the
+// repository file and, if extensions.worktreeConfig is enabled, the worktree file. The
+// GIT_CONFIG_SYSTEM, GIT_CONFIG_NOSYSTEM and GIT_CONFIG_GLOBAL environment variables are
+// honoured, as are include.path and includeIf.<condition>.path with the gitdir, gitdir/i
+// and onbranch conditions. Files that do not exist are skipped.
+func ReadConfig(gitDir string) (*Config, error) {
+	r := &configReader{gitDir: gitDir, values: make(map[string]string)}
+
+	if os.Getenv("GIT_CONFIG_NOSYSTEM") == "" {
+		system := os.Getenv("GIT_CONFIG_SYSTEM")
+		if system == "" {
+			system = "/etc/gitconfig"
+		}
+		if err := r.readFile(system, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	if global := os.Getenv("GIT_CONFIG_GLOBAL"); global != "" {
+		if err := r.readFile(global, 0); err != nil {
+			return nil, err
+		}
+	} else {
+		if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+			if err := r.readFile(filepath.Join(xdg, "git", "config"), 0); err != nil {
+				return nil, err
+			}
+		} else if home, err := os.UserHomeDir(); err == nil {
+			if err := r.readFile(filepath.Join(home, ".config", "git", "config"), 0); err != nil {
+				return nil, err
+			}
+		}
+		if home, err := os.UserHomeDir(); err == nil {
+			if err := r.readFile(filepath.Join(home, ".gitconfig"), 0); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	commonDir := gitDir
+	if data, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		commonDir = strings.TrimSpace(string(data))
+		if !filepath.IsAbs(commonDir) {
+			commonDir = filepath.Join(gitDir, commonDir)
+		}
+	}
+	if err := r.readFile(filepath.Join(commonDir, "config"), 0); err != nil {
+		return nil, err
+	}
+	if ok, _ := r.bool("extensions.worktreeconfig"); ok {
+		if err := r.readFile(filepath.Join(gitDir, "config.worktree"), 0); err != nil {
+			return nil, err
+		}
+	}
+
+	c := &Config{ExcludesFile: r.string("core.excludesfile")}
+	var err error
+	if c.IgnoreCase, err = r.bool("core.ignorecase"); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// configReader collects the values of a sequence of configuration files with later
+// values overriding earlier ones. Keys are made of the lower-case section name, the
+// subsection name as is and the lower-case variable name joined with dots.
+type configReader struct {
+	gitDir string
+	values map[string]string
+}
+
+// implicitTrue marks variables given without "=" which are true as booleans.
+const implicitTrue = "\x00true"
+
+func (r *configReader) string(key string) string {
+	if value := r.values[key]; value != implicitTrue {
+		return value
+	}
+	return ""
+}
+
+func (r *configReader) bool(key string) (bool, error) {
+	value, ok := r.values[key]
+	if !ok {
+		return false, nil
+	}
+	switch strings.ToLower(value) {
+	case implicitTrue, "true", "yes", "on":
+		return true, nil
+	case "false", "no", "off", "":
+		return false, nil
+	}
+	if n, err := strconv.ParseInt(value, 0, 64); err == nil {
+		return n != 0, nil
+	}
+	return false, fmt.Errorf("bad boolean config value %q for %s", value, key)
+}
+
+func (r *configReader) readFile(filename string, depth int) error {
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	p := &configParser{data: data, filename: filename, line: 1}
+	return p.parse(func(key, value string) error {
+		return r.set(filename, key, value, depth)
+	})
+}
+
+func (r *configReader) set(filename, key, value string, depth int) error {
+	r.values[key] = value
+	include := ""
+	if key == "include.path" {
+		include = value
+	} else if strings.HasPrefix(key, "includeif.") && strings.HasSuffix(key, ".path") {
+		if r.condition(filename, key[len("includeif."):len(key)-len(".path")]) {
+			include = value
+		}
+	}
+	if include == "" {
+		return nil
+	} else if include == implicitTrue {
+		return fmt.Errorf("%s: missing value for %s", filename, key)
+	}
+	if depth >= maxIncludeDepth {
+		return fmt.Errorf("%s: exceeded maximum include depth (%d)", filename, maxIncludeDepth)
+	}
+	include, err := expandHome(include)
+	if err != nil {
+		return err
+	}
+	if !filepath.IsAbs(include) {
+		include = filepath.Join(filepath.Dir(filename), include)
+	}
+	return r.readFile(include, depth+1)
+}
+
+// condition evaluates the condition of an includeIf section.
+func (r *configReader) condition(filename, condition string) bool {
+	if pattern, ok := strings.CutPrefix(condition, "gitdir:"); ok {
+		return r.gitDirMatches(filename, pattern, false)
+	} else if pattern, ok := strings.CutPrefix(condition, "gitdir/i:"); ok {
+		return r.gitDirMatches(filename, pattern, true)
+	} else if pattern, ok := strings.CutPrefix(condition, "onbranch:"); ok {
+		head, err := os.ReadFile(filepath.Join(r.gitDir, "HEAD"))
+		if err != nil {
+			return false
+		}
+		branch, ok := strings.CutPrefix(strings.TrimSpace(string(head)), "ref: refs/heads/")
+		if !ok {
+			return false
+		}
+		if strings.HasSuffix(pattern, "/") {
+			pattern += "**"
+		}
+		return pathMatches(pattern, branch, false)
+	}
+	return false
+}
+
+func (r *configReader) gitDirMatches(filename, pattern string, fold bool) bool {
+	// expanding the home directory drops the trailing slash
+	dir := strings.HasSuffix(pattern, "/")
+	if strings.HasPrefix(pattern, "./") {
+		pattern = filepath.ToSlash(filepath.Dir(filename)) + pattern[1:]
+	} else if strings.HasPrefix(pattern, "~") {
+		var err error
+		if pattern, err = expandHome(pattern); err != nil {
+			return false
+		}
+		pattern = filepath.ToSlash(pattern)
+	} else if !strings.HasPrefix(pattern, "/") && !filepath.IsAbs(pattern) {
+		pattern = "**/" + pattern
+	}
+	if dir {
+		pattern = strings.TrimSuffix(pattern, "/") + "/**"
+	}
+	gitDir, err := filepath.Abs(r.gitDir)
+	if err != nil {
+		return false
+	}
+	if pathMatches(pattern, filepath.ToSlash(gitDir), fold) {
+		return true
+	}
+	real, err := filepath.EvalSymlinks(gitDir)
+	return err == nil && pathMatches(pattern, filepath.ToSlash(real), fold)
+}
+
+// pathMatches matches a slash separated path against a glob where "**" segments match
+// any number of directories and other wildcards do not match a slash.
+func pathMatches(pattern, path string, fold bool) bool {
+	p := &ptrn{isGlob: true, fold: fold}
+	p.pattern, _ = splitPattern(strings.TrimPrefix(pattern, "/"))
+	return p.match(strings.Split(strings.TrimPrefix(path, "/"), "/"), true, matchExact) > NoMatch
+}
+
+// configParser parses the git configuration file syntax.
+type configParser struct {
+	data     []byte
+	pos      int
+	filename string
+	line     int
+}
+
+func (p *configParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s:%d: %s", p.filename, p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *configParser) peek() byte {
+	if p.pos < len(p.data) {
+		return p.data[p.pos]
+	}
+	return 0
+}
+
+func (p *configParser) next() byte {
+	c := p.peek()
+	if p.pos < len(p.data) {
+		p.pos++
+	}
+	if c == '\n' {
+		p.line++
+	}
+	return c
+}
+
+func (p *configParser) skipLine() {
+	for p.pos < len(p.data) && p.next() != '\n' {
+	}
+}
+
+func (p *configParser) parse(set func(key, value string) error) error {
+	// a UTF-8 BOM is allowed at the start of the file
+	if strings.HasPrefix(string(p.data), "\xef\xbb\xbf") {
+		p.pos = 3
+	}
+	section := ""
+	for p.pos < len(p.data) {
+		c := p.peek()
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			p.next()
+		case c == '#' || c == ';':
+			p.skipLine()
+		case c == '[':
+			var err error
+			if section, err = p.parseSection(); err != nil {
+				return err
+			}
+		case isAlpha(rune(c)):
+			if section == "" {
+				return p.errorf("variable outside of a section")
+			}
+			name, value, err := p.parseVariable()
+			if err != nil {
+				return err
+			}
+			if err := set(section+"."+name, value); err != nil {
+				return err
+			}
+		default:
+			return p.errorf("bad config line")
+		}
+	}
+	return nil
+}
+
+func (p *configParser) parseSection() (string, error) {
+	p.next()
+	var name []byte
+	for isConfigNameChar(p.peek()) || p.peek() == '.' {
+		name = append(name, p.next())
+	}
+	section := strings.ToLower(string(name))
+	if p.peek() == ']' {
+		// also covers the deprecated [section.subsection] syntax with a lower-case subsection
+		p.next()
+		return section, nil
+	}
+	if len(name) == 0 || strings.IndexByte(section, '.') >= 0 {
+		return "", p.errorf("bad section header")
+	}
+	for p.peek() == ' ' || p.peek() == '\t' {
+		p.next()
+	}
+	if p.next() != '"' {
+		return "", p.errorf("bad section header")
+	}
+	var subsection []byte
+	for {
+		c := p.next()
+		switch c {
+		case 0, '\n':
+			return "", p.errorf("bad section header")
+		case '\\':
+			if c = p.next(); c == 0 || c == '\n' {
+				return "", p.errorf("bad section header")
+			}
+		case '"':
+			if p.next() != ']' {
+				return "", p.errorf("bad section header")
+			}
+			return section + "." + string(subsection), nil
+		}
+		subsection = append(subsection, c)
+	}
+}
+
+func (p *configParser) parseVariable() (name, value string, err error) {
+	var b []byte
+	for isConfigNameChar(p.peek()) {
+		b = append(b, p.next())
+	}
+	name = strings.ToLower(string(b))
+	for p.peek() == ' ' || p.peek() == '\t' {
+		p.next()
+	}
+	switch p.peek() {
+	case '=':
+		p.next()
+	case 0, '\r', '\n', '#', ';':
+		p.skipLine()
+		return name, implicitTrue, nil
+	default:
+		return "", "", p.errorf("bad config line")
+	}
+
+	var v []byte
+	end, quoted := 0, false
+	for p.peek() == ' ' || p.peek() == '\t' {
+		p.next()
+	}
+	for {
+		c := p.next()
+		switch {
+		case c == 0 || c == '\n':
+			if quoted {
+				return "", "", p.errorf("unterminated quote")
+			}
+			return name, string(v[:end]), nil
+		case !quoted && (c == '#' || c == ';'):
+			p.skipLine()
+			return name, string(v[:end]), nil
+		case c == '"':
+			quoted = !quoted
+			end = len(v)
+			continue
+		case c == '\\':
+			switch c = p.next(); c {
+			case '\n':
+				continue
+			case '\r':
+				if p.peek() == '\n' {
+					p.next()
+					continue
+				}
+				return "", "", p.errorf("bad escape sequence")
+			case 'n':
+				c = '\n'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case '"', '\\':
+			default:
+				return "", "", p.errorf("bad escape sequence")
+			}
+			v = append(v, c)
+			end = len(v)
+			continue
+		}
+		v = append(v, c)
+		if quoted || c != ' ' && c != '\t' && c != '\r' {
+			end = len(v)
+		}
+	}
+}
+
+func isConfigNameChar(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-'
+}
@@ -0,0 +1,182 @@
+// Copyright (c) 2017. Oleg Sklyar & teris.io. All rights reserved.
+// See the LICENSE file in the project root for licensing information.
+
+package gitignore_test
+
+import (
+	"github.com/teris-io/gitignore"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// configEnv isolates the configuration from the user and system files.
+func configEnv(t *testing.T) (home string) {
+	home = t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_CONFIG_GLOBAL", "")
+	return home
+}
+
+func TestReadConfig_syntax(t *testing.T) {
+	configEnv(t)
+	gitDir := t.TempDir()
+	writeFile(t, gitDir, "config", `# comment
+[Core]  ; comment
+	IgnoreCase
+	excludesFile = "/path with  spaces/\"ignore\"" # comment
+[core "sub"]
+	excludesFile = other
+`)
+	config, err := gitignore.ReadConfig(gitDir)
+	if err != nil {
+		t.Fatalf("no error expected, found %v", err)
+	}
+	if !config.IgnoreCase {
+		t.Errorf("expected ignoreCase")
+	}
+	if expected := `/path with  spaces/"ignore"`; config.ExcludesFile != expected {
+		t.Errorf("expected %q, found %q", expected, config.ExcludesFile)
+	}
+}
+
+func TestReadConfig_values(t *testing.T) {
+	configEnv(t)
+	cases := map[string]bool{
+		"ignoreCase":            true,
+		"ignoreCase = yes":      true,
+		"ignoreCase = On":       true,
+		"ignoreCase = 1":        true,
+		"ignoreCase = false":    false,
+		"ignoreCase = off":      false,
+		"ignoreCase =":          false,
+		"ignoreCase = 0":        false,
+		"ignoreCase = t\\\nrue": true,
+	}
+	for line, expected := range cases {
+		gitDir := t.TempDir()
+		writeFile(t, gitDir, "config", "[core]\n"+line+"\n")
+		config, err := gitignore.ReadConfig(gitDir)
+		if err != nil {
+			t.Errorf("%q: no error expected, found %v", line, err)
+		} else if config.IgnoreCase != expected {
+			t.Errorf("%q: expected %v, found %v", line, expected, config.IgnoreCase)
+		}
+	}
+}
+
+func TestReadConfig_errors(t *testing.T) {
+	configEnv(t)
+	for _, content := range []string{
+		"[core]\nignoreCase = maybe\n",
+		"ignoreCase = true\n",
+		"[core\nignoreCase = true\n",
+		"[core]\nexcludesFile = \"unterminated\n",
+		"[core]\nexcludesFile = bad\\escape\n",
+		"[core]\n=value\n",
+	} {
+		gitDir := t.TempDir()
+		writeFile(t, gitDir, "config", content)
+		if _, err := gitignore.ReadConfig(gitDir); err == nil {
+			t.Errorf("%q: expected an error", content)
+		}
+	}
+}
+
+func TestReadConfig_precedence(t *testing.T) {
+	home := configEnv(t)
+	gitDir := t.TempDir()
+	writeFile(t, home, ".config/git/config", "[core]\nexcludesFile = xdg\nignoreCase = true\n")
+	writeFile(t, home, ".gitconfig", "[core]\nexcludesFile = global\n")
+	config, err := gitignore.ReadConfig(gitDir)
+	if err != nil {
+		t.Fatalf("no error expected, found %v", err)
+	}
+	if config.ExcludesFile != "global" || !config.IgnoreCase {
+		t.Errorf("expected global and ignoreCase, found %+v", config)
+	}
+
+	writeFile(t, gitDir, "config", "[core]\nexcludesFile = local\n[extensions]\nworktreeConfig = true\n")
+	writeFile(t, gitDir, "config.worktree", "[core]\nignoreCase = false\n")
+	if config, err = gitignore.ReadConfig(gitDir); err != nil {
+		t.Fatalf("no error expected, found %v", err)
+	}
+	if config.ExcludesFile != "local" || config.IgnoreCase {
+		t.Errorf("expected local and no ignoreCase, found %+v", config)
+	}
+
+	system := filepath.Join(t.TempDir(), "gitconfig")
+	writeFile(t, filepath.Dir(system), "gitconfig", "[core]\nexcludesFile = system\n")
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "")
+	t.Setenv("GIT_CONFIG_SYSTEM", system)
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(home, "missing"))
+	if config, err = gitignore.ReadConfig(t.TempDir()); err != nil {
+		t.Fatalf("no error expected, found %v", err)
+	}
+	if config.ExcludesFile != "system" {
+		t.Errorf("expected system, found %+v", config)
+	}
+}
+
+func TestReadConfig_include(t *testing.T) {
+	home := configEnv(t)
+	writeFile(t, home, ".gitconfig", "[include]\n\tpath = conf/extra\n[core]\n\tignoreCase = true\n")
+	writeFile(t, home, "conf/extra", "[core]\n\tignoreCase = false\n\texcludesFile = extra\n")
+	config, err := gitignore.ReadConfig(t.TempDir())
+	if err != nil {
+		t.Fatalf("no error expected, found %v", err)
+	}
+	if config.ExcludesFile != "extra" || !config.IgnoreCase {
+		t.Errorf("expected extra and ignoreCase, found %+v", config)
+	}
+}
+
+func TestReadConfig_includeCycle(t *testing.T) {
+	home := configEnv(t)
+	writeFile(t, home, ".gitconfig", "[include]\n\tpath = ~/.gitconfig\n")
+	if _, err := gitignore.ReadConfig(t.TempDir()); err == nil || !strings.Contains(err.Error(), "include depth") {
+		t.Errorf("expected an include depth error, found %v", err)
+	}
+}
+
+func TestReadConfig_includeIf(t *testing.T) {
+	home := configEnv(t)
+	work := filepath.Join(home, "Work", "repo", ".git")
+	other := filepath.Join(home, "other", ".git")
+	writeFile(t, work, "HEAD", "ref: refs/heads/feature/ignore\n")
+	writeFile(t, other, "HEAD", "ref: refs/heads/master\n")
+
+	for _, tc := range []struct {
+		condition string
+		matches   bool
+	}{
+		{"gitdir:~/Work/", true},
+		{"gitdir:~/Work", false},
+		{"gitdir:~/work/", false},
+		{"gitdir/i:~/work/", true},
+		{"gitdir/i:work/repo/.git", true},
+		{"gitdir:Work/", true},
+		{"gitdir:" + filepath.ToSlash(filepath.Join(home, "Work")) + "/", true},
+		{"onbranch:feature/", true},
+		{"onbranch:feature", false},
+	} {
+		writeFile(t, home, ".gitconfig", "[includeIf \""+tc.condition+"\"]\n\tpath = included\n")
+		writeFile(t, home, "included", "[core]\n\texcludesFile = "+tc.condition+"\n\tignoreCase = true\n")
+
+		config, err := gitignore.ReadConfig(work)
+		if err != nil {
+			t.Fatalf("no error expected, found %v", err)
+		}
+		if expected := tc.matches; (config.ExcludesFile == tc.condition) != expected || config.IgnoreCase != expected {
+			t.Errorf("%s: expected included %v, found %+v", tc.condition, expected, config)
+		}
+		if config, err = gitignore.ReadConfig(other); err != nil {
+			t.Fatalf("no error expected, found %v", err)
+		}
+		if config.ExcludesFile != "" || config.IgnoreCase {
+			t.Errorf("%s: expected no settings, found %+v", tc.condition, config)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+// Copyright (c) 2017. Oleg Sklyar & teris.io. All rights reserved.
+// See the LICENSE file in the project root for licensing information.
+
+package gitignore
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Severity defines how serious a problem reported by a Diagnostic is.
+type Severity int
+
+const (
+	// SeverityWarning marks a pattern that is valid, but likely not doing what was meant
+	SeverityWarning Severity = iota + 1
+	// SeverityError marks a pattern that is malformed and never matches
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return "Severity(" + strconv.Itoa(int(s)) + ")"
+}
+
+// Diagnostic describes a problem found in a pattern while parsing it.
+type Diagnostic struct {
+	Severity Severity
+	Message  string
+	// Source is the file the pattern was read from, empty if not read from a file
+	Source string
+	// Line is the 1-based line number of the pattern in the source, 0 if unknown
+	Line int
+	// Start and End delimit the offending part of the pattern as 0-based byte offsets
+	// with End exclusive
+	Start int
+	End   int
+}
+
+// Error formats the diagnostic as "source:line:column: severity: message" with the
+// column 1-based and the parts that are unknown left out.
+func (d Diagnostic) Error() string {
+	var b strings.Builder
+	if d.Source != "" {
+		b.WriteString(d.Source)
+		b.WriteByte(':')
+	}
+	if d.Line > 0 {
+		b.WriteString(strconv.Itoa(d.Line))
+		b.WriteByte(':')
+	}
+	b.WriteString(strconv.Itoa(d.Start + 1))
+	b.WriteString(": ")
+	b.WriteString(d.Severity.String())
+	b.WriteString(": ")
+	b.WriteString(d.Message)
+	return b.String()
+}
+
+// ParsePatternStrict parses a gitignore pattern string like ParsePattern does, but fails
+// with the first error found, a Diagnostic, if the pattern is malformed.
+func ParsePatternStrict(pattern string, domain []string) (Pattern, error) {
+	p, diagnostics := ParsePatternWithDiagnostics(pattern, domain, ParseOptions{})
+	if d := firstError(diagnostics); d != nil {
+		return nil, *d
+	}
+	return p, nil
+}
+
+// ParsePatternWithDiagnostics parses a gitignore pattern string with the given options
+// and reports the problems found in it. Errors are reported for malformed patterns that
+// never match: an unclosed bracket expression, an unknown POSIX character class and a
+// dangling backslash. Warnings are reported for patterns that match differently than
+// they seem to, such as a "**" that is not a whole path segment. The pattern is returned
+// in either case and matches as with ParsePattern.
+func ParsePatternWithDiagnostics(pattern string, domain []string, options ParseOptions) (Pattern, []Diagnostic) {
+	p := ParsePatternWithOptions(pattern, domain, options).(*ptrn)
+	diagnostics := checkPattern(pattern, p)
+	for i := range diagnostics {
+		diagnostics[i].Source, diagnostics[i].Line = options.Origin.Source, options.Origin.Line
+	}
+	return p, diagnostics
+}
+
+// firstError returns the first of the diagnostics that is an error, nil if none.
+func firstError(diagnostics []Diagnostic) *Diagnostic {
+	for i := range diagnostics {
+		if diagnostics[i].Severity == SeverityError {
+			return &diagnostics[i]
+		}
+	}
+	return nil
+}
+
+// checkPattern scans the pattern text as parsed into p for malformed constructs.
+func checkPattern(text string, p *ptrn) (diagnostics []Diagnostic) {
+	report := func(severity Severity, start, end int, message string) {
+		diagnostics = append(diagnostics, Diagnostic{Severity: severity, Message: message, Start: start, End: end})
+	}
+
+	start := 0
+	if p.inclusion {
+		start = 1
+	}
+	end := start + len(trimTrailingSpaces(text[start:]))
+	if p.dirOnly {
+		end--
+	}
+	if start == end {
+		report(SeverityWarning, 0, len(text), "empty pattern matches nothing")
+		return
+	}
+
+	segStart := start
+	for i := start; i < end; {
+		if w := separatorAt(text[:end], i); w > 0 {
+			i += w
+			segStart = i
+			continue
+		}
+		switch text[i] {
+		case '\\':
+			if i+1 == end {
+				report(SeverityError, i, i+1, "dangling backslash, the pattern never matches")
+			}
+			i += 2
+		case '*':
+			j := i
+			for j < end && text[j] == '*' {
+				j++
+			}
+			if j-i > 1 && (i != segStart || j < end && separatorAt(text[:end], j) == 0) {
+				report(SeverityWarning, i, j, `consecutive asterisks match like a single "*"`)
+			}
+			i = j
+		case '[':
+			width, errStart, errEnd, message := checkClass(text[:end], i)
+			if message != "" {
+				report(SeverityError, errStart, errEnd, message+", the pattern never matches")
+			}
+			i += width
+		default:
+			i++
+		}
+	}
+	return
+}
+
+// checkClass validates the bracket expression starting at offset i of the pattern the
+// same way matchClass does, and returns its width, which may span a slash as with
+// splitPattern, or on failure the width up to the end of the path segment and the
+// offending range with a message.
+func checkClass(pattern string, i int) (width, errStart, errEnd int, message string) {
+	j := i + 1
+	if j < len(pattern) && (pattern[j] == '!' || pattern[j] == '^') {
+		j++
+	}
+	unclosed := func() (int, int, int, string) {
+		k := i + 1
+		for k < len(pattern) && separatorAt(pattern, k) == 0 {
+			k++
+		}
+		return k - i, i, k, "unclosed bracket expression"
+	}
+	for first := true; ; first = false {
+		if j >= len(pattern) {
+			return unclosed()
+		}
+		c := pattern[j]
+		if c == ']' && !first {
+			return j + 1 - i, 0, 0, ""
+		}
+		if c == '[' && j+1 < len(pattern) && pattern[j+1] == ':' {
+			if n := strings.IndexByte(pattern[j+2:], ']'); n > 0 && pattern[j+2+n-1] == ':' {
+				class := pattern[j+2 : j+2+n-1]
+				if _, known := posixClass(class, 0); !known {
+					k := j + 2 + n + 1
+					for k < len(pattern) && separatorAt(pattern, k) == 0 {
+						k++
+					}
+					return k - i, j, j + 2 + n + 1, "unknown character class [:" + class + ":]"
+				}
+				j += 2 + n + 1
+				continue
+			}
+		}
+		if c == '\\' {
+			j++
+		}
+		j++
+	}
+}
+
+// separatorAt returns the width of the path separator, "/" or "\/", at offset i of the
+// pattern and 0 if there is none.
+func separatorAt(pattern string, i int) int {
+	if pattern[i] == '/' {
+		return 1
+	}
+	if pattern[i] == '\\' && i+1 < len(pattern) && pattern[i+1] == '/' {
+		return 2
+	}
+	return 0
+}
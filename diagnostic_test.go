@@ -0,0 +1,79 @@
+// Copyright (c) 2017. Oleg Sklyar & teris.io. All rights reserved.
+// See the LICENSE file in the project root for licensing information.
+
+package gitignore_test
+
+import (
+	"github.com/teris-io/gitignore"
+	"testing"
+)
+
+func TestParsePatternWithDiagnostics(t *testing.T) {
+	cases := []struct {
+		pattern  string
+		severity gitignore.Severity
+		start    int
+		end      int
+	}{
+		{"a/b***c", gitignore.SeverityWarning, 3, 6},
+		{"foo**bar/baz", gitignore.SeverityWarning, 3, 5},
+		{"a/foo**bar", gitignore.SeverityWarning, 5, 7},
+		{"a***b", gitignore.SeverityWarning, 1, 4},
+		{"foo**bar", gitignore.SeverityWarning, 3, 5},
+		{"foo/v[ou", gitignore.SeverityError, 5, 8},
+		{"v[ou/x", gitignore.SeverityError, 1, 4},
+		{"!v[[:foo:]]", gitignore.SeverityError, 3, 10},
+		{"foo\\", gitignore.SeverityError, 3, 4},
+		{"!", gitignore.SeverityWarning, 0, 1},
+	}
+	for _, tc := range cases {
+		_, diagnostics := gitignore.ParsePatternWithDiagnostics(tc.pattern, nil, gitignore.ParseOptions{})
+		if len(diagnostics) != 1 {
+			t.Errorf("%s: expected 1 diagnostic, found %v", tc.pattern, diagnostics)
+			continue
+		}
+		d := diagnostics[0]
+		if d.Severity != tc.severity || d.Start != tc.start || d.End != tc.end {
+			t.Errorf("%s: expected %v at %d-%d, found %v at %d-%d", tc.pattern, tc.severity, tc.start, tc.end, d.Severity, d.Start, d.End)
+		}
+	}
+}
+
+func TestParsePatternWithDiagnostics_Valid(t *testing.T) {
+	for _, pattern := range []string{
+		"**", "**/foo", "a/**/b", "foo/**", "*.go", "v[ou]l[[:alpha:]]ano", "[]]", "[!a-z]",
+		"a\\**", "a/[**]/b", "a/***/b", "***/x", "[a/b]", "x/[!a/b]y", "\\!important", "trailing\\ ", "dir/", "a\\/b", "x[\\]]",
+	} {
+		if _, diagnostics := gitignore.ParsePatternWithDiagnostics(pattern, nil, gitignore.ParseOptions{}); len(diagnostics) != 0 {
+			t.Errorf("%s: expected no diagnostics, found %v", pattern, diagnostics)
+		}
+	}
+}
+
+func TestParsePatternWithDiagnostics_Origin(t *testing.T) {
+	options := gitignore.ParseOptions{Origin: gitignore.Origin{Source: "a/.gitignore", Line: 7}}
+	_, diagnostics := gitignore.ParsePatternWithDiagnostics("x/[ab", nil, options)
+	if len(diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic, found %d", len(diagnostics))
+	}
+	if msg := diagnostics[0].Error(); msg != "a/.gitignore:7:3: error: unclosed bracket expression, the pattern never matches" {
+		t.Errorf("expected a formatted diagnostic, found %q", msg)
+	}
+}
+
+func TestParsePatternStrict(t *testing.T) {
+	if _, err := gitignore.ParsePatternStrict("a/b[c", nil); err == nil {
+		t.Error("expected an error")
+	} else if d, ok := err.(gitignore.Diagnostic); !ok || d.Severity != gitignore.SeverityError {
+		t.Errorf("expected an error diagnostic, found %v", err)
+	}
+	for _, pattern := range []string{"a***b", "x/a**b"} {
+		p, err := gitignore.ParsePatternStrict(pattern, nil)
+		if err != nil {
+			t.Fatalf("%s: expected warnings not to fail, found %v", pattern, err)
+		}
+		if res := p.Match([]string{"x", "axyb"}, false); res != gitignore.Exclude {
+			t.Errorf("%s: expected Exclude, found %v", pattern, res)
+		}
+	}
+}
@@ -4,6 +4,7 @@
 package gitignore
 
 import (
+	"bytes"
 	"strings"
 )
 
@@ -21,11 +22,7 @@ type Dir interface {
 // structure. The result is in the ascending order of priority (last higher).
 func ReadPatterns(dir Dir) (patterns []Pattern, err error) {
 	if data, err := dir.ReadFile(".gitignore"); err == nil {
-		for _, s := range strings.Split(string(data), "\n") {
-			if !strings.HasPrefix(s, "#") && len(strings.TrimSpace(s)) > 0 {
-				patterns = append(patterns, ParsePattern(s, dir.Path()))
-			}
-		}
+		patterns = parsePatterns(data, dir.Path(), Origin{Source: gitignoreSource(dir.Path()), Layer: GitignoreLayer})
 	}
 
 	var subdirs []Dir
@@ -48,3 +45,22 @@ func ReadPatterns(dir Dir) (patterns []Pattern, err error) {
 
 	return
 }
+
+// parsePatterns parses the content of a gitignore file into patterns in the order of
+// definition skipping blank lines and comments. The patterns take the source and the
+// layer from the given origin and record their lines. Line endings are normalised and a
+// byte order mark is skipped as with ParseReader. Content exceeding the size limit is
+// ignored altogether as git does.
+func parsePatterns(data []byte, domain []string, origin Origin) []Pattern {
+	patterns, _, err := ParseReader(bytes.NewReader(data), domain, ParseOptions{Origin: origin})
+	if err != nil {
+		return nil
+	}
+	return patterns
+}
+
+// gitignoreSource returns the slash separated path of the .gitignore file in the
+// directory relative to the root of the tree.
+func gitignoreSource(dir []string) string {
+	return strings.Join(append(dir[:len(dir):len(dir)], ".gitignore"), "/")
+}
@@ -7,6 +7,8 @@ import (
 	"github.com/teris-io/gitignore"
 	"testing"
 	"fmt"
+	"os"
+	"path/filepath"
 )
 
 type dir struct {
@@ -57,7 +59,11 @@ func TestDir_ReadPatterns(t *testing.T) {
 	if !matcher.Match([]string{"vendor", "gopkg.in"}, true) {
 		t.Error("expected a match")
 	}
-	if matcher.Match([]string{"vendor", "github.com"}, true) {
+	if !matcher.Match([]string{"vendor", "github.com"}, true) {
+		t.Error("expected a match as the parent directory is excluded")
+	}
+	lenient := gitignore.NewMatcherWithOptions(patterns, gitignore.MatcherOptions{Lenient: true})
+	if lenient.Match([]string{"vendor", "github.com"}, true) {
 		t.Error("expected no match")
 	}
 }
@@ -71,3 +77,77 @@ func TestDir_ReadPatterns_error(t *testing.T) {
 	}
 
 }
+
+type contentDir struct {
+	content string
+}
+
+func (d *contentDir) Path() []string {
+	return nil
+}
+
+func (d *contentDir) ReadFile(name string) ([]byte, error) {
+	if name != ".gitignore" {
+		return nil, fmt.Errorf("no such file")
+	}
+	return []byte(d.content), nil
+}
+
+func (d *contentDir) Subdirs() ([]gitignore.Dir, error) {
+	return nil, nil
+}
+
+func TestDir_ReadPatterns_escapedComment(t *testing.T) {
+	patterns, err := gitignore.ReadPatterns(&contentDir{content: "# comment\n\\#hash\n\n   \n"})
+	if err != nil {
+		t.Errorf("no error expected, found %v", err)
+	}
+	if len(patterns) != 1 {
+		t.Fatalf("expected 1 pattern, found %v", len(patterns))
+	}
+	if res := patterns[0].Match([]string{"#hash"}, false); res != gitignore.Exclude {
+		t.Errorf("expected Exclude, found %v", res)
+	}
+}
+
+func TestDir_ReadPatterns_origin(t *testing.T) {
+	patterns, err := gitignore.ReadPatterns(&dir{})
+	if err != nil {
+		t.Errorf("no error expected, found %v", err)
+	}
+	expected := []gitignore.Origin{
+		{Source: ".gitignore", Line: 1, Raw: "vendor/", Layer: gitignore.GitignoreLayer},
+		{Source: "vendor/.gitignore", Line: 1, Raw: "!github.com/", Layer: gitignore.GitignoreLayer},
+	}
+	for i, pattern := range patterns {
+		if origin := pattern.Origin(); origin != expected[i] {
+			t.Errorf("expected %+v, found %+v", expected[i], origin)
+		}
+	}
+}
+
+func TestDir_ReadPatterns_lineEndings(t *testing.T) {
+	for _, name := range []string{"crlf.gitignore", "bom.gitignore", "bom-crlf.gitignore"} {
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		patterns, err := gitignore.ReadPatterns(&contentDir{content: string(data)})
+		if err != nil {
+			t.Errorf("%s: no error expected, found %v", name, err)
+		}
+		if len(patterns) != 3 {
+			t.Fatalf("%s: expected 3 patterns, found %v", name, len(patterns))
+		}
+		m := gitignore.NewMatcher(patterns)
+		if !m.Match([]string{"debug.log"}, false) {
+			t.Errorf("%s: expected debug.log to be excluded", name)
+		}
+		if m.Match([]string{"keep.log"}, false) {
+			t.Errorf("%s: expected keep.log to be included", name)
+		}
+		if !m.Match([]string{"build"}, true) {
+			t.Errorf("%s: expected build/ to be excluded", name)
+		}
+	}
+}
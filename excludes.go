@@ -0,0 +1,100 @@
+// Copyright (c) 2017. Oleg Sklyar & teris.io. All rights reserved.
+// See the LICENSE file in the project root for licensing information.
+
+package gitignore
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+)
+
+// ReadExcludes reads the patterns of the exclude files of the repository with the given
+// git directory that live outside of the work tree: $GIT_DIR/info/exclude and the file
+// named by the core.excludesFile setting as resolved by ReadConfig, which defaults to
+// $XDG_CONFIG_HOME/git/ignore. The patterns have an empty domain and are meant for the
+// InfoExcludeLayer and the ExcludesFileLayer of a LayeredMatcher. Files that do not
+// exist are skipped.
+func ReadExcludes(gitDir string) (infoExclude, excludesFile []Pattern, err error) {
+	if infoExclude, err = ReadInfoExclude(gitDir); err != nil {
+		return
+	}
+	var config *Config
+	if config, err = ReadConfig(gitDir); err != nil {
+		return
+	}
+	excludesFile, err = ReadExcludesFile(config.ExcludesFile)
+	return
+}
+
+// ReadInfoExclude reads the patterns of $GIT_DIR/info/exclude.
+func ReadInfoExclude(gitDir string) ([]Pattern, error) {
+	return readExcludeFile(filepath.Join(gitDir, "info", "exclude"), InfoExcludeLayer)
+}
+
+// ReadExcludesFile reads the patterns of the file named by the value of the
+// core.excludesFile setting, or of the default file when the value is empty.
+func ReadExcludesFile(value string) ([]Pattern, error) {
+	filename, err := ExcludesFilePath(value)
+	if err != nil || filename == "" {
+		return nil, err
+	}
+	return readExcludeFile(filename, ExcludesFileLayer)
+}
+
+// ExcludesFilePath resolves the value of the core.excludesFile setting into a file name
+// expanding a leading "~/" or "~user/". An empty value resolves to the default file
+// $XDG_CONFIG_HOME/git/ignore or, when XDG_CONFIG_HOME is not set, ~/.config/git/ignore.
+// As with git, there is no default file, and the name is empty, when neither is set
+// nor the home directory known.
+func ExcludesFilePath(value string) (string, error) {
+	if value == "" {
+		if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+			return filepath.Join(xdg, "git", "ignore"), nil
+		}
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", nil
+		}
+		return filepath.Join(home, ".config", "git", "ignore"), nil
+	}
+	return expandHome(value)
+}
+
+// expandHome expands a leading "~" or "~user" in the path into the home directory.
+func expandHome(path string) (string, error) {
+	if !strings.HasPrefix(path, "~") {
+		return path, nil
+	}
+	name, rest := path[1:], ""
+	if i := strings.IndexByte(name, '/'); i >= 0 {
+		name, rest = name[:i], name[i+1:]
+	}
+	var home string
+	if name == "" {
+		var err error
+		if home, err = os.UserHomeDir(); err != nil {
+			return "", err
+		}
+	} else {
+		u, err := user.Lookup(name)
+		if err != nil {
+			return "", err
+		}
+		home = u.HomeDir
+	}
+	return filepath.Join(home, filepath.FromSlash(rest)), nil
+}
+
+func readExcludeFile(filename string, layer Layer) ([]Pattern, error) {
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return parsePatterns(data, nil, Origin{Source: filename, Layer: layer}), nil
+}
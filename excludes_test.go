@@ -0,0 +1,112 @@
+// Copyright (c) 2017. Oleg Sklyar & teris.io. All rights reserved.
+// See the LICENSE file in the project root for licensing information.
+
+package gitignore_test
+
+import (
+	"github.com/teris-io/gitignore"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadExcludes_defaultExcludesFile(t *testing.T) {
+	home := configEnv(t)
+	gitDir := t.TempDir()
+	writeFile(t, gitDir, "info/exclude", "# local\n*.local\n")
+	writeFile(t, home, ".config/git/ignore", "*.swp\n.DS_Store\n")
+
+	info, global, err := gitignore.ReadExcludes(gitDir)
+	if err != nil {
+		t.Fatalf("no error expected, found %v", err)
+	}
+	if len(info) != 1 || len(global) != 2 {
+		t.Fatalf("expected 1 and 2 patterns, found %v and %v", len(info), len(global))
+	}
+	if res := global[0].Match([]string{"src", "main.go.swp"}, false); res != gitignore.Exclude {
+		t.Errorf("expected Exclude, found %v", res)
+	}
+	expected := gitignore.Origin{Source: filepath.Join(gitDir, "info", "exclude"), Line: 2, Raw: "*.local", Layer: gitignore.InfoExcludeLayer}
+	if origin := info[0].Origin(); origin != expected {
+		t.Errorf("expected %+v, found %+v", expected, origin)
+	}
+	expected = gitignore.Origin{Source: filepath.Join(home, ".config", "git", "ignore"), Line: 2, Raw: ".DS_Store", Layer: gitignore.ExcludesFileLayer}
+	if origin := global[1].Origin(); origin != expected {
+		t.Errorf("expected %+v, found %+v", expected, origin)
+	}
+}
+
+func TestReadExcludes_xdgExcludesFile(t *testing.T) {
+	configEnv(t)
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	writeFile(t, xdg, "git/ignore", "*.swp\n")
+
+	info, global, err := gitignore.ReadExcludes(t.TempDir())
+	if err != nil {
+		t.Fatalf("no error expected, found %v", err)
+	}
+	if len(info) != 0 || len(global) != 1 {
+		t.Errorf("expected 0 and 1 patterns, found %v and %v", len(info), len(global))
+	}
+}
+
+func TestReadExcludes_coreExcludesFile(t *testing.T) {
+	home := configEnv(t)
+	writeFile(t, home, ".gitconfig", "[core]\n\texcludesFile = ~/global-ignore\n")
+	writeFile(t, home, "global-ignore", "*.global\n")
+	writeFile(t, home, "repo-ignore", "*.repo\n*.bak\n")
+	writeFile(t, home, ".config/git/ignore", "*.swp\n*.tmp\n*.bak\n")
+
+	_, global, err := gitignore.ReadExcludes(t.TempDir())
+	if err != nil {
+		t.Fatalf("no error expected, found %v", err)
+	}
+	if len(global) != 1 {
+		t.Errorf("expected 1 pattern, found %v", len(global))
+	}
+
+	gitDir := t.TempDir()
+	writeFile(t, gitDir, "config", "[core]\n\texcludesfile = \"~/repo-ignore\"\n")
+	_, global, err = gitignore.ReadExcludes(gitDir)
+	if err != nil {
+		t.Fatalf("no error expected, found %v", err)
+	}
+	if len(global) != 2 {
+		t.Errorf("expected 2 patterns, found %v", len(global))
+	}
+}
+
+func TestReadExcludes_noHome(t *testing.T) {
+	configEnv(t)
+	t.Setenv("HOME", "")
+	gitDir := t.TempDir()
+	writeFile(t, gitDir, "info/exclude", "*.local\n")
+
+	info, global, err := gitignore.ReadExcludes(gitDir)
+	if err != nil {
+		t.Fatalf("no error expected, found %v", err)
+	}
+	if len(info) != 1 || len(global) != 0 {
+		t.Errorf("expected 1 and 0 patterns, found %v and %v", len(info), len(global))
+	}
+
+	writeFile(t, gitDir, "config", "[core]\n\texcludesFile = ~/ignore\n")
+	if _, _, err := gitignore.ReadExcludes(gitDir); err == nil {
+		t.Error("expected an error for a configured file in an unknown home directory")
+	}
+}
+
+func TestExcludesFilePath(t *testing.T) {
+	home := configEnv(t)
+	cases := map[string]string{
+		"":                filepath.Join(home, ".config", "git", "ignore"),
+		"~/ignore":        filepath.Join(home, "ignore"),
+		"/etc/gitignore":  "/etc/gitignore",
+		"relative/ignore": "relative/ignore",
+	}
+	for value, expected := range cases {
+		if path, err := gitignore.ExcludesFilePath(value); err != nil || path != expected {
+			t.Errorf("%q: expected %v, found %v, %v", value, expected, path, err)
+		}
+	}
+}
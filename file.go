@@ -0,0 +1,198 @@
+// Copyright (c) 2017. Oleg Sklyar & teris.io. All rights reserved.
+// See the LICENSE file in the project root for licensing information.
+
+package gitignore
+
+import (
+	"bytes"
+	"io"
+	"strings"
+)
+
+// File is an editable gitignore document. It keeps comments, blank lines, the order of
+// the lines, their endings and a byte order mark, so that an unmodified document is
+// written back byte for byte and a modified one differs only in the edited lines.
+// Paths given to the editing operations are slash separated and relative to the
+// directory of the document, with a trailing slash marking a directory.
+type File struct {
+	domain  []string
+	options ParseOptions
+	bom     bool
+	lines   []fileLine
+}
+
+type fileLine struct {
+	text string
+	// eol is the line ending as read, empty for a last line without one
+	eol string
+}
+
+// ParseFile parses the content of a gitignore document defining patterns for the given
+// domain. The options apply to the patterns of the document, the line numbers of their
+// origin follow the current content.
+func ParseFile(data []byte, domain []string, options ParseOptions) *File {
+	f := &File{domain: domain, options: options}
+	if bytes.HasPrefix(data, []byte("\xef\xbb\xbf")) {
+		f.bom = true
+		data = data[3:]
+	}
+	for s := string(data); s != ""; {
+		line := fileLine{text: s}
+		if i := strings.IndexByte(s, '\n'); i >= 0 {
+			line.text, line.eol, s = s[:i], "\n", s[i+1:]
+			if strings.HasSuffix(line.text, "\r") {
+				line.text, line.eol = line.text[:len(line.text)-1], "\r\n"
+			}
+		} else {
+			s = ""
+		}
+		f.lines = append(f.lines, line)
+	}
+	return f
+}
+
+// Bytes returns the content of the document.
+func (f *File) Bytes() []byte {
+	var b bytes.Buffer
+	f.WriteTo(&b)
+	return b.Bytes()
+}
+
+// WriteTo writes the content of the document to the writer.
+func (f *File) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	if f.bom {
+		b.WriteString("\xef\xbb\xbf")
+	}
+	for _, line := range f.lines {
+		b.WriteString(line.text)
+		b.WriteString(line.eol)
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// Patterns returns the patterns of the document in the order of definition.
+func (f *File) Patterns() []Pattern {
+	var patterns []Pattern
+	options := f.options
+	for i, line := range f.lines {
+		if isPatternLine(line.text) {
+			options.Origin.Line, options.Origin.Raw = i+1, line.text
+			patterns = append(patterns, ParsePatternWithOptions(line.text, f.domain, options))
+		}
+	}
+	return patterns
+}
+
+// Add appends the pattern as a new line at the end of the document.
+func (f *File) Add(pattern string) {
+	eol := "\n"
+	if len(f.lines) > 0 && f.lines[0].eol != "" {
+		eol = f.lines[0].eol
+	}
+	if n := len(f.lines); n > 0 && f.lines[n-1].eol == "" {
+		f.lines[n-1].eol = eol
+	}
+	f.lines = append(f.lines, fileLine{text: pattern, eol: eol})
+}
+
+// Remove removes all lines defining the pattern, ignoring unescaped trailing spaces, and
+// reports whether any was found.
+func (f *File) Remove(pattern string) bool {
+	pattern = trimTrailingSpaces(pattern)
+	lines := f.lines[:0]
+	for _, line := range f.lines {
+		if isPatternLine(line.text) && trimTrailingSpaces(line.text) == pattern {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	removed := len(lines) < len(f.lines)
+	f.lines = lines
+	return removed
+}
+
+// EnsureIgnored makes the path excluded by the document, unless it already is, by
+// appending a pattern anchored at the directory of the document. It reports whether the
+// document was modified.
+func (f *File) EnsureIgnored(path string) bool {
+	names, isDir := splitEditPath(path)
+	if len(names) == 0 || f.matcher().Match(f.fullPath(names), isDir) {
+		return false
+	}
+	f.Add(anchoredPattern("", names, isDir))
+	return true
+}
+
+// EnsureIncluded makes the path not excluded by the document by appending negated
+// patterns. Git does not look into excluded directories, so an excluded parent
+// directory is re-included first while its other content stays excluded: including
+// "a/b/c" with "a/" excluded appends "!/a/", "/a/*", "!/a/b/", "/a/b/*" and "!/a/b/c".
+// It reports whether the document was modified.
+func (f *File) EnsureIncluded(path string) bool {
+	names, isDir := splitEditPath(path)
+	if len(names) == 0 {
+		return false
+	}
+	modified := false
+	// every round re-includes at least one more path component
+	for range names {
+		e := f.matcher().Explain(f.fullPath(names), isDir)
+		if e.Result != Exclude {
+			break
+		}
+		modified = true
+		if e.Parent == nil {
+			f.Add(anchoredPattern("!", names, isDir))
+			break
+		}
+		parent := e.Parent[len(f.domain):]
+		f.Add(anchoredPattern("!", parent, true))
+		f.Add(anchoredPattern("", parent, false) + "/*")
+	}
+	return modified
+}
+
+func (f *File) matcher() Matcher {
+	return NewMatcherWithOptions(f.Patterns(), MatcherOptions{CaseInsensitive: f.options.CaseInsensitive})
+}
+
+func (f *File) fullPath(names []string) []string {
+	return append(f.domain[:len(f.domain):len(f.domain)], names...)
+}
+
+// splitEditPath splits a slash separated path into its components and reports whether
+// it names a directory.
+func splitEditPath(path string) (names []string, isDir bool) {
+	isDir = strings.HasSuffix(path, "/")
+	for _, name := range strings.Split(path, "/") {
+		if name != "" && name != "." {
+			names = append(names, name)
+		}
+	}
+	return names, isDir
+}
+
+// anchoredPattern returns a pattern matching exactly the path relative to the directory
+// of the document with all wildcards escaped.
+func anchoredPattern(prefix string, names []string, isDir bool) string {
+	var b strings.Builder
+	b.WriteString(prefix)
+	for _, name := range names {
+		b.WriteByte('/')
+		for i := 0; i < len(name); i++ {
+			switch c := name[i]; {
+			case c == '\\' || c == '*' || c == '?' || c == '[':
+				b.WriteByte('\\')
+			case c == ' ' && strings.TrimRight(name[i:], " ") == "":
+				b.WriteByte('\\')
+			}
+			b.WriteByte(name[i])
+		}
+	}
+	if isDir {
+		b.WriteByte('/')
+	}
+	return b.String()
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2017. Oleg Sklyar & teris.io. All rights reserved.
+// See the LICENSE file in the project root for licensing information.
+
+package gitignore_test
+
+import (
+	"bytes"
+	"github.com/teris-io/gitignore"
+	"testing"
+)
+
+func TestFile_roundTrip(t *testing.T) {
+	for _, content := range []string{
+		"",
+		"# comment\n\n*.log\n!keep.log   \nbuild/\n",
+		"\xef\xbb\xbf*.log\r\n\r\n# comment\r\nbuild/",
+		"mixed\r\nendings\n",
+	} {
+		f := gitignore.ParseFile([]byte(content), nil, gitignore.ParseOptions{})
+		if data := f.Bytes(); string(data) != content {
+			t.Errorf("expected %q, found %q", content, data)
+		}
+	}
+}
+
+func TestFile_Patterns(t *testing.T) {
+	f := gitignore.ParseFile([]byte("# comment\n*.log\n\n!keep.log\n"), []string{"a"}, gitignore.ParseOptions{})
+	patterns := f.Patterns()
+	if len(patterns) != 2 {
+		t.Fatalf("expected 2 patterns, found %d", len(patterns))
+	}
+	if origin := patterns[1].Origin(); origin.Line != 4 || origin.Raw != "!keep.log" {
+		t.Errorf("expected line 4, found %+v", origin)
+	}
+	if res := patterns[0].Match([]string{"a", "x.log"}, false); res != gitignore.Exclude {
+		t.Errorf("expected Exclude, found %v", res)
+	}
+}
+
+func TestFile_AddRemove(t *testing.T) {
+	f := gitignore.ParseFile([]byte("# generated\r\n*.log\r\ntmp/"), nil, gitignore.ParseOptions{})
+	f.Add("*.out")
+	if expected := "# generated\r\n*.log\r\ntmp/\r\n*.out\r\n"; string(f.Bytes()) != expected {
+		t.Errorf("expected %q, found %q", expected, f.Bytes())
+	}
+	if !f.Remove("*.log  ") {
+		t.Error("expected *.log to be removed")
+	}
+	if f.Remove("# generated") {
+		t.Error("expected comments not to be removed")
+	}
+	if expected := "# generated\r\ntmp/\r\n*.out\r\n"; string(f.Bytes()) != expected {
+		t.Errorf("expected %q, found %q", expected, f.Bytes())
+	}
+}
+
+func TestFile_EnsureIgnored(t *testing.T) {
+	f := gitignore.ParseFile([]byte("*.log\n!keep.log\n"), nil, gitignore.ParseOptions{})
+	if f.EnsureIgnored("a/debug.log") {
+		t.Error("expected a/debug.log to be already ignored")
+	}
+	if !f.EnsureIgnored("a/keep.log") || !f.EnsureIgnored("b[1]/*/") || !f.EnsureIgnored("trailing ") {
+		t.Error("expected the document to be modified")
+	}
+	if expected := "*.log\n!keep.log\n/a/keep.log\n/b\\[1]/\\*/\n/trailing\\ \n"; string(f.Bytes()) != expected {
+		t.Errorf("expected %q, found %q", expected, f.Bytes())
+	}
+	m := gitignore.NewMatcher(f.Patterns())
+	for _, tc := range []struct {
+		path  []string
+		isDir bool
+	}{
+		{[]string{"a", "keep.log"}, false},
+		{[]string{"b[1]", "*"}, true},
+		{[]string{"trailing "}, false},
+	} {
+		if !m.Match(tc.path, tc.isDir) {
+			t.Errorf("expected %v to be excluded", tc.path)
+		}
+	}
+	if m.Match([]string{"b1", "x"}, true) {
+		t.Error("expected b1/x not to be excluded")
+	}
+}
+
+func TestFile_EnsureIncluded(t *testing.T) {
+	f := gitignore.ParseFile([]byte("a/\n"), nil, gitignore.ParseOptions{})
+	if !f.EnsureIncluded("a/b/c") {
+		t.Error("expected the document to be modified")
+	}
+	if expected := "a/\n!/a/\n/a/*\n!/a/b/\n/a/b/*\n!/a/b/c\n"; string(f.Bytes()) != expected {
+		t.Errorf("expected %q, found %q", expected, f.Bytes())
+	}
+	m := gitignore.NewMatcher(f.Patterns())
+	if m.Match([]string{"a", "b", "c"}, false) {
+		t.Error("expected a/b/c to be included")
+	}
+	if !m.Match([]string{"a", "x"}, false) || !m.Match([]string{"a", "b", "x"}, false) {
+		t.Error("expected the rest of a/ to stay excluded")
+	}
+	if f.EnsureIncluded("a/b/c") {
+		t.Error("expected a/b/c to be already included")
+	}
+}
+
+func TestFile_EnsureIncluded_domain(t *testing.T) {
+	f := gitignore.ParseFile([]byte("*.log\n"), []string{"sub"}, gitignore.ParseOptions{})
+	if !f.EnsureIncluded("keep.log") {
+		t.Error("expected the document to be modified")
+	}
+	if !bytes.HasSuffix(f.Bytes(), []byte("\n!/keep.log\n")) {
+		t.Errorf("expected a negation, found %q", f.Bytes())
+	}
+	if gitignore.NewMatcher(f.Patterns()).Match([]string{"sub", "keep.log"}, false) {
+		t.Error("expected sub/keep.log to be included")
+	}
+}
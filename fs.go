@@ -0,0 +1,188 @@
+// Copyright (c) 2017. Oleg Sklyar & teris.io. All rights reserved.
+// See the LICENSE file in the project root for licensing information.
+
+package gitignore
+
+import (
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// NewFSDir constructs a Dir over the root of the given file system such as embed.FS,
+// fstest.MapFS, os.DirFS or zip.Reader. As with NewOSDir, directories named .git and
+// sub-directories containing a .git directory or file are not traversed.
+func NewFSDir(fsys fs.FS) Dir {
+	return &fsDir{fsys: fsys}
+}
+
+type fsDir struct {
+	fsys fs.FS
+	path []string
+}
+
+func (d *fsDir) Path() []string {
+	return d.path
+}
+
+func (d *fsDir) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(d.fsys, path.Join(d.name(), name))
+}
+
+func (d *fsDir) Subdirs() ([]Dir, error) {
+	entries, err := fs.ReadDir(d.fsys, d.name())
+	if err != nil {
+		return nil, err
+	}
+	var subdirs []Dir
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == ".git" {
+			continue
+		}
+		subdirPath := append(d.path[:len(d.path):len(d.path)], entry.Name())
+		subdir := &fsDir{fsys: d.fsys, path: subdirPath}
+		if _, err := fs.Stat(d.fsys, path.Join(subdir.name(), ".git")); err == nil {
+			continue
+		}
+		subdirs = append(subdirs, subdir)
+	}
+	return subdirs, nil
+}
+
+func (d *fsDir) name() string {
+	if len(d.path) == 0 {
+		return "."
+	}
+	return strings.Join(d.path, "/")
+}
+
+// NewFilteredFS wraps the file system hiding all files and directories excluded by the
+// matcher. Excluded entries are reported as not existing by Open, Stat and ReadDir and
+// are neither listed in directories nor returned by Glob, so that fs.WalkDir, http.FS
+// or template.ParseFS only ever see the tree that is not ignored. As with Walk, .git
+// directories and files and the content of nested repositories are hidden as well.
+func NewFilteredFS(fsys fs.FS, matcher Matcher) fs.FS {
+	return &filteredFS{fsys: fsys, matcher: matcher}
+}
+
+type filteredFS struct {
+	fsys    fs.FS
+	matcher Matcher
+}
+
+func (f *filteredFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	file, err := f.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if f.ignored(name, fi.IsDir()) {
+		file.Close()
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	if fi.IsDir() {
+		return &filteredDir{File: file, fs: f, name: name}, nil
+	}
+	return file, nil
+}
+
+func (f *filteredFS) Stat(name string) (fs.FileInfo, error) {
+	fi, err := fs.Stat(f.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	if f.ignored(name, fi.IsDir()) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return fi, nil
+}
+
+func (f *filteredFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if _, err := f.Stat(name); err != nil {
+		return nil, err
+	}
+	entries, err := fs.ReadDir(f.fsys, name)
+	return f.filter(name, entries), err
+}
+
+func (f *filteredFS) Glob(pattern string) ([]string, error) {
+	names, err := fs.Glob(f.fsys, pattern)
+	if err != nil {
+		return nil, err
+	}
+	var res []string
+	for _, name := range names {
+		if _, err := f.Stat(name); err == nil {
+			res = append(res, name)
+		}
+	}
+	return res, nil
+}
+
+func (f *filteredFS) ignored(name string, isDir bool) bool {
+	if name == "." {
+		return false
+	}
+	path := strings.Split(name, "/")
+	for i, e := range path {
+		if e == ".git" {
+			return true
+		}
+		if i > 0 && f.isRepository(path[:i]) {
+			return true
+		}
+	}
+	return f.matcher.Match(path, isDir)
+}
+
+// isRepository tells whether the directory is the root of a nested repository.
+func (f *filteredFS) isRepository(dir []string) bool {
+	_, err := fs.Stat(f.fsys, strings.Join(dir, "/")+"/.git")
+	return err == nil
+}
+
+func (f *filteredFS) filter(dir string, entries []fs.DirEntry) []fs.DirEntry {
+	var res []fs.DirEntry
+	for _, entry := range entries {
+		if !f.ignored(path.Join(dir, entry.Name()), entry.IsDir()) {
+			res = append(res, entry)
+		}
+	}
+	return res
+}
+
+type filteredDir struct {
+	fs.File
+	fs   *filteredFS
+	name string
+}
+
+func (d *filteredDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rd, ok := d.File.(fs.ReadDirFile)
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: d.name, Err: fs.ErrInvalid}
+	}
+	if n <= 0 {
+		entries, err := rd.ReadDir(n)
+		return d.fs.filter(d.name, entries), err
+	}
+	var res []fs.DirEntry
+	for len(res) < n {
+		entries, err := rd.ReadDir(n - len(res))
+		res = append(res, d.fs.filter(d.name, entries)...)
+		if err == io.EOF && len(res) > 0 {
+			return res, nil
+		} else if err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2017. Oleg Sklyar & teris.io. All rights reserved.
+// See the LICENSE file in the project root for licensing information.
+
+package gitignore_test
+
+import (
+	"github.com/teris-io/gitignore"
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		".gitignore":                 {Data: []byte("*.log\nvendor/\n")},
+		"main.go":                    {Data: []byte("package main\n")},
+		"debug.log":                  {Data: []byte("debug\n")},
+		"src/.gitignore":             {Data: []byte("!keep.log\n")},
+		"src/keep.log":               {Data: []byte("keep\n")},
+		"src/drop.log":               {Data: []byte("drop\n")},
+		"vendor/lib/lib.go":          {Data: []byte("package lib\n")},
+		"module/.git":                {Data: []byte("gitdir: ../.git/modules/module\n")},
+		"module/.gitignore":          {Data: []byte("module\n")},
+		".git/info/exclude":          {Data: []byte("\n")},
+		".git/.gitignore":            {Data: []byte("git\n")},
+		"vendor/lib/.gitignore":      {Data: []byte("!*.log\n")},
+		"vendor/lib/nested/file.txt": {Data: []byte("\n")},
+	}
+}
+
+func TestFSDir_ReadPatterns(t *testing.T) {
+	patterns, err := gitignore.ReadPatterns(gitignore.NewFSDir(testFS()))
+	if err != nil {
+		t.Fatalf("no error expected, found %v", err)
+	}
+	if len(patterns) != 4 {
+		t.Fatalf("expected 4 patterns, found %v", len(patterns))
+	}
+	if path := []string{"vendor", "lib", "x.log"}; patterns[3].Match(path, false) != gitignore.Include {
+		t.Errorf("expected Include for %v", path)
+	}
+}
+
+func TestFilteredFS(t *testing.T) {
+	fsys := testFS()
+	patterns, err := gitignore.ReadPatterns(gitignore.NewFSDir(fsys))
+	if err != nil {
+		t.Fatalf("no error expected, found %v", err)
+	}
+	filtered := gitignore.NewFilteredFS(fsys, gitignore.NewMatcher(patterns))
+	if err := fstest.TestFS(filtered, "main.go", "src/keep.log", "module"); err != nil {
+		t.Error(err)
+	}
+
+	var found []string
+	err = fs.WalkDir(filtered, ".", func(path string, d fs.DirEntry, err error) error {
+		if !d.IsDir() {
+			found = append(found, path)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatalf("no error expected, found %v", err)
+	}
+	expected := []string{".gitignore", "main.go", "src/.gitignore", "src/keep.log"}
+	if !reflect.DeepEqual(found, expected) {
+		t.Errorf("expected %v, found %v", expected, found)
+	}
+}
+
+func TestFilteredFS_hidden(t *testing.T) {
+	fsys := testFS()
+	patterns, _ := gitignore.ReadPatterns(gitignore.NewFSDir(fsys))
+	filtered := gitignore.NewFilteredFS(fsys, gitignore.NewMatcher(patterns))
+	for _, name := range []string{"debug.log", "src/drop.log", "vendor", "vendor/lib/lib.go",
+		".git", ".git/info/exclude", "module/.git", "module/.gitignore"} {
+		if _, err := filtered.Open(name); err == nil {
+			t.Errorf("expected %v to be hidden from Open", name)
+		}
+		if _, err := fs.Stat(filtered, name); err == nil {
+			t.Errorf("expected %v to be hidden from Stat", name)
+		}
+	}
+	if names, err := fs.Glob(filtered, "*/*.log"); err != nil || !reflect.DeepEqual(names, []string{"src/keep.log"}) {
+		t.Errorf("expected [src/keep.log], found %v, %v", names, err)
+	}
+}
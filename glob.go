@@ -0,0 +1,209 @@
+// Copyright (c) 2017. Oleg Sklyar & teris.io. All rights reserved.
+// See the LICENSE file in the project root for licensing information.
+
+package gitignore
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// matchName matches a single path component against a single pattern segment using
+// git's fnmatch rules independently of the host OS: "*" matches any sequence, "?" any
+// character, "[...]" a character class which is negated by a leading "!" or "^" and may
+// contain ranges and POSIX classes such as "[:alpha:]", and a backslash escapes the
+// character that follows. A malformed pattern, such as one with an unclosed bracket or a
+// dangling backslash, matches nothing. With fold set characters are compared under
+// Unicode simple case folding.
+//
+// Unlike in git, matching is performed per Unicode code point. This is a deliberate
+// extension: git's wildmatch compares bytes, so that there "?" matches a single byte of
+// "é" rather than the character and "[é]" does not match "é" at all. Patterns with
+// only ASCII characters in wildcards and brackets match the same in both.
+func matchName(pattern, name string, fold bool) bool {
+	px, nx := 0, 0
+	starPx, starNx := -1, -1
+	for {
+		if px < len(pattern) {
+			switch pattern[px] {
+			case '*':
+				for px < len(pattern) && pattern[px] == '*' {
+					px++
+				}
+				starPx, starNx = px, nx
+				continue
+			case '?':
+				if nx < len(name) {
+					_, w := utf8.DecodeRuneInString(name[nx:])
+					px++
+					nx += w
+					continue
+				}
+			case '[':
+				if nx < len(name) {
+					r, w := utf8.DecodeRuneInString(name[nx:])
+					matched, n, valid := matchClass(pattern[px:], r, fold)
+					if !valid {
+						return false
+					}
+					if matched {
+						px += n
+						nx += w
+						continue
+					}
+				}
+			case '\\':
+				if px+1 == len(pattern) {
+					return false
+				}
+				px++
+				fallthrough
+			default:
+				if nx < len(name) {
+					pr, pw := utf8.DecodeRuneInString(pattern[px:])
+					r, w := utf8.DecodeRuneInString(name[nx:])
+					if pr == r || fold && equalFold(pr, r) {
+						px += pw
+						nx += w
+						continue
+					}
+				}
+			}
+		} else if nx == len(name) {
+			return true
+		}
+		if starPx < 0 || starNx == len(name) {
+			return false
+		}
+		_, w := utf8.DecodeRuneInString(name[starNx:])
+		starNx += w
+		px, nx = starPx, starNx
+	}
+}
+
+// matchClass matches a rune against the bracket expression at the start of the
+// pattern. It returns whether the rune matched, the width of the bracket expression
+// and whether the expression is well formed.
+func matchClass(pattern string, r rune, fold bool) (matched bool, width int, valid bool) {
+	i := 1
+	negate := false
+	if i < len(pattern) && (pattern[i] == '!' || pattern[i] == '^') {
+		negate = true
+		i++
+	}
+	for first := true; ; first = false {
+		if i >= len(pattern) {
+			return false, 0, false
+		}
+		c := pattern[i]
+		if c == ']' && !first {
+			i++
+			break
+		}
+		if c == '[' && i+1 < len(pattern) && pattern[i+1] == ':' {
+			if end := strings.IndexByte(pattern[i+2:], ']'); end > 0 && pattern[i+2+end-1] == ':' {
+				class := pattern[i+2 : i+2+end-1]
+				if _, known := posixClass(class, r); !known {
+					return false, 0, false
+				}
+				for f, ok := r, true; ok && !matched; f, ok = nextFold(r, f, fold) {
+					matched, _ = posixClass(class, f)
+				}
+				i += 2 + end + 1
+				continue
+			}
+		}
+		if c == '\\' {
+			if i++; i >= len(pattern) {
+				return false, 0, false
+			}
+		}
+		lo, w := utf8.DecodeRuneInString(pattern[i:])
+		i += w
+		if i+1 < len(pattern) && pattern[i] == '-' && pattern[i+1] != ']' {
+			i++
+			if pattern[i] == '\\' {
+				if i++; i >= len(pattern) {
+					return false, 0, false
+				}
+			}
+			hi, w := utf8.DecodeRuneInString(pattern[i:])
+			i += w
+			matched = matched || inRange(lo, hi, r, fold)
+			continue
+		}
+		matched = matched || inRange(lo, lo, r, fold)
+	}
+	return matched != negate, i, true
+}
+
+// inRange reports whether the rune, or with fold set any rune equivalent to it under
+// simple case folding, is within the range.
+func inRange(lo, hi, r rune, fold bool) bool {
+	for c, ok := r, true; ok; c, ok = nextFold(r, c, fold) {
+		if lo <= c && c <= hi {
+			return true
+		}
+	}
+	return false
+}
+
+// nextFold returns the rune following c in the simple case folding orbit of r, and false
+// when the orbit is exhausted or fold is not set.
+func nextFold(r, c rune, fold bool) (rune, bool) {
+	if !fold {
+		return c, false
+	}
+	c = unicode.SimpleFold(c)
+	return c, c != r
+}
+
+func equalFold(a, b rune) bool {
+	for c, ok := a, true; ok; c, ok = nextFold(a, c, true) {
+		if c == b {
+			return true
+		}
+	}
+	return false
+}
+
+// posixClass reports whether the rune belongs to the named POSIX character class and
+// whether the class is known. As in git, classes only ever contain ASCII characters.
+func posixClass(class string, r rune) (is bool, known bool) {
+	switch class {
+	case "alnum":
+		return isAlpha(r) || isDigit(r), true
+	case "alpha":
+		return isAlpha(r), true
+	case "blank":
+		return r == ' ' || r == '\t', true
+	case "cntrl":
+		return r < ' ' || r == 0x7f, true
+	case "digit":
+		return isDigit(r), true
+	case "graph":
+		return r > ' ' && r < 0x7f, true
+	case "lower":
+		return 'a' <= r && r <= 'z', true
+	case "print":
+		return r >= ' ' && r < 0x7f, true
+	case "punct":
+		return r > ' ' && r < 0x7f && !isAlpha(r) && !isDigit(r), true
+	case "space":
+		return r == ' ' || '\t' <= r && r <= '\r', true
+	case "upper":
+		return 'A' <= r && r <= 'Z', true
+	case "xdigit":
+		return isDigit(r) || 'a' <= r && r <= 'f' || 'A' <= r && r <= 'F', true
+	}
+	return false, false
+}
+
+func isAlpha(r rune) bool {
+	return 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z'
+}
+
+func isDigit(r rune) bool {
+	return '0' <= r && r <= '9'
+}
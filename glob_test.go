@@ -0,0 +1,89 @@
+// Copyright (c) 2017. Oleg Sklyar & teris.io. All rights reserved.
+// See the LICENSE file in the project root for licensing information.
+
+package gitignore_test
+
+import (
+	"github.com/teris-io/gitignore"
+	"testing"
+)
+
+// globConformance lists single segment patterns together with the outcome reported by
+// `git check-ignore --no-index` for a file of the given name. The table does not depend
+// on the host OS.
+var globConformance = []struct {
+	pattern string
+	name    string
+	match   bool
+}{
+	{"a*b*c", "axxbyyc", true},
+	{"a*b*c", "axxbyy", false},
+	{"*.go", "main.go", true},
+	{"*.go", "main.goo", false},
+	{"*", "abc", true},
+	{"a?c", "abc", true},
+	{"a?c", "ac", false},
+	{"[^a]b", "xb", true},
+	{"[!a]b", "ab", false},
+	{"[!a-c]x", "dx", true},
+	{"[!a-c]x", "bx", false},
+	{"[a-c]", "b", true},
+	{"[a-c]x", "B", false},
+	{"[]a]", "]", true},
+	{"[!]a]", "b", true},
+	{"[]]", "]", true},
+	{"[a-]", "-", true},
+	{"[[]", "[", true},
+	{`[\]]`, "]", true},
+	{`[a\-c]`, "b", false},
+	{`[a\-c]`, "-", true},
+	{`a[\*]b`, "a*b", true},
+	{`a[\*]b`, "axb", false},
+	{"[[:alpha:]]x", "ax", true},
+	{"[[:digit:]]x", "ax", false},
+	{"[[:digit:][:upper:]]x", "Ax", true},
+	{"[[:space:]]x", " x", true},
+	{"[[:punct:]]x", "$x", true},
+	{"[[:xdigit:]]x", "gx", false},
+	{"[[:alnum:]_]x", "_x", true},
+	{"[[:foo:]]", "a", false},
+	{"[a", "[a", false},
+	{"a[", "a[", false},
+	{"[!]", "!", false},
+	{"[]", "]", false},
+	{`a\`, `a\`, false},
+}
+
+func TestPatternNameMatch_gitConformance(t *testing.T) {
+	for _, tc := range globConformance {
+		expected := gitignore.NoMatch
+		if tc.match {
+			expected = gitignore.Exclude
+		}
+		if res := gitignore.ParsePattern(tc.pattern, nil).Match([]string{tc.name}, false); res != expected {
+			t.Errorf("%q on %q: expected %v, found %v", tc.pattern, tc.name, expected, res)
+		}
+		if res := gitignore.ParsePattern("/head/"+tc.pattern, nil).Match([]string{"head", tc.name}, false); res != expected {
+			t.Errorf("/head/%q on %q: expected %v, found %v", tc.pattern, tc.name, expected, res)
+		}
+	}
+}
+
+func TestPatternNameMatch_backslashIsNotSeparator(t *testing.T) {
+	pattern := gitignore.ParsePattern(`a\\b`, nil)
+	if res := pattern.Match([]string{`a\b`}, false); res != gitignore.Exclude {
+		t.Errorf("expected Exclude, found %v", res)
+	}
+	if res := pattern.Match([]string{"a", "b"}, false); res != gitignore.NoMatch {
+		t.Errorf("expected NoMatch, found %v", res)
+	}
+}
+
+// TestPatternNameMatch_unicode covers matching per code point, an extension over git,
+// which matches bytes and does not ignore "grüßeö" with this pattern.
+func TestPatternNameMatch_unicode(t *testing.T) {
+	pattern := gitignore.ParsePattern("gr?ße[äö]", nil)
+	if res := pattern.Match([]string{"grüßeö"}, false); res != gitignore.Exclude {
+		t.Errorf("expected Exclude, found %v", res)
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2017. Oleg Sklyar & teris.io. All rights reserved.
+// See the LICENSE file in the project root for licensing information.
+
+package gitignore
+
+import (
+	"sort"
+	"sync"
+)
+
+// Layer defines a source of exclude patterns. Layers are listed in the order of
+// increasing priority as defined by git.
+type Layer int
+
+const (
+	// UnspecifiedLayer is the layer of patterns not read from any exclude source, it has
+	// the lowest priority in a LayeredMatcher
+	UnspecifiedLayer Layer = iota
+	// ExcludesFileLayer holds patterns of the file named by core.excludesFile, or of the
+	// default $XDG_CONFIG_HOME/git/ignore
+	ExcludesFileLayer
+	// InfoExcludeLayer holds patterns of $GIT_DIR/info/exclude
+	InfoExcludeLayer
+	// GitignoreLayer holds patterns of the .gitignore files of the work tree
+	GitignoreLayer
+	// CommandLineLayer holds patterns given on the command line
+	CommandLineLayer
+
+	numLayers = iota
+)
+
+func (l Layer) String() string {
+	switch l {
+	case UnspecifiedLayer:
+		return "unspecified"
+	case ExcludesFileLayer:
+		return "core.excludesFile"
+	case InfoExcludeLayer:
+		return "info/exclude"
+	case GitignoreLayer:
+		return ".gitignore"
+	case CommandLineLayer:
+		return "command line"
+	}
+	return "unknown"
+}
+
+// LayeredMatcher matches paths against patterns from all of git's exclude sources with
+// git's precedence: command line patterns override those of the .gitignore files, which
+// override $GIT_DIR/info/exclude, which overrides core.excludesFile. Within the
+// .gitignore layer the patterns of a deeper directory override those of its parents.
+// Each layer can be replaced at any time and concurrently with matching.
+type LayeredMatcher struct {
+	mu      sync.RWMutex
+	options MatcherOptions
+	layers  [numLayers][]Pattern
+	matcher *matcher
+}
+
+// NewLayeredMatcher constructs a new layered matcher with all layers empty.
+func NewLayeredMatcher(options MatcherOptions) *LayeredMatcher {
+	return &LayeredMatcher{options: options, matcher: newMatcher(nil, options)}
+}
+
+// SetLayer replaces the patterns of the layer. Patterns of a layer must be given in the
+// order of definition, but the patterns of the .gitignore layer may come in any order of
+// directories.
+func (m *LayeredMatcher) SetLayer(layer Layer, patterns []Pattern) {
+	patterns = append([]Pattern(nil), patterns...)
+	if layer == GitignoreLayer {
+		sort.SliceStable(patterns, func(i, j int) bool {
+			return len(domainOf(patterns[i])) < len(domainOf(patterns[j]))
+		})
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.layers[layer] = patterns
+	var all []Pattern
+	for _, patterns := range m.layers {
+		all = append(all, patterns...)
+	}
+	m.matcher = newMatcher(all, m.options)
+}
+
+// Layer returns the patterns of the layer.
+func (m *LayeredMatcher) Layer(layer Layer) []Pattern {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.layers[layer]
+}
+
+// Match matches the path against the patterns of all layers.
+func (m *LayeredMatcher) Match(path []string, isDir bool) bool {
+	m.mu.RLock()
+	matcher := m.matcher
+	m.mu.RUnlock()
+	return matcher.Match(path, isDir)
+}
+
+// MatchPath matches a path given as a string against the patterns of all layers.
+func (m *LayeredMatcher) MatchPath(path string, isDir bool) (bool, error) {
+	return matchPath(m, path, isDir)
+}
+
+// MatchFile matches a file of the file system tree rooted at root against the patterns
+// of all layers.
+func (m *LayeredMatcher) MatchFile(root, path string) (bool, error) {
+	return matchFile(m, root, path)
+}
+
+// MatchString matches a clean slash separated path against the patterns of all layers
+// without allocating, see Matcher.
+func (m *LayeredMatcher) MatchString(path string, isDir bool) bool {
+	m.mu.RLock()
+	matcher := m.matcher
+	m.mu.RUnlock()
+	return matcher.MatchString(path, isDir)
+}
+
+// MatchBytes matches a path as MatchString does without copying the bytes.
+func (m *LayeredMatcher) MatchBytes(path []byte, isDir bool) bool {
+	return m.MatchString(bytesToString(path), isDir)
+}
+
+// Result matches the path against the patterns of all layers and returns the outcome.
+func (m *LayeredMatcher) Result(path []string, isDir bool) MatchResult {
+	m.mu.RLock()
+	matcher := m.matcher
+	m.mu.RUnlock()
+	return matcher.Result(path, isDir)
+}
+
+// Explain matches the path against the patterns of all layers and reports the pattern
+// that decided the outcome.
+func (m *LayeredMatcher) Explain(path []string, isDir bool) Explanation {
+	m.mu.RLock()
+	matcher := m.matcher
+	m.mu.RUnlock()
+	return matcher.Explain(path, isDir)
+}
+
+// domainOf returns the directory of the gitignore file defining the pattern. Patterns
+// implemented outside of this package are assumed to apply to the whole tree.
+func domainOf(pattern Pattern) []string {
+	if p, ok := pattern.(*ptrn); ok {
+		return p.domain
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2017. Oleg Sklyar & teris.io. All rights reserved.
+// See the LICENSE file in the project root for licensing information.
+
+package gitignore_test
+
+import (
+	"github.com/teris-io/gitignore"
+	"testing"
+)
+
+func TestLayeredMatcher_precedence(t *testing.T) {
+	m := gitignore.NewLayeredMatcher(gitignore.MatcherOptions{})
+	m.SetLayer(gitignore.CommandLineLayer, []gitignore.Pattern{
+		gitignore.ParsePattern("cmd.txt", nil),
+	})
+	m.SetLayer(gitignore.ExcludesFileLayer, []gitignore.Pattern{
+		gitignore.ParsePattern("*.log", nil),
+		gitignore.ParsePattern("*.swp", nil),
+		gitignore.ParsePattern("cmd.txt", nil),
+	})
+	m.SetLayer(gitignore.InfoExcludeLayer, []gitignore.Pattern{
+		gitignore.ParsePattern("!debug.log", nil),
+		gitignore.ParsePattern("local/", nil),
+	})
+	m.SetLayer(gitignore.GitignoreLayer, []gitignore.Pattern{
+		gitignore.ParsePattern("!keep.swp", []string{"src"}),
+		gitignore.ParsePattern("!cmd.txt", nil),
+		gitignore.ParsePattern("*.swp", nil),
+		gitignore.ParsePattern("!local/", nil),
+	})
+
+	cases := []struct {
+		path     []string
+		isDir    bool
+		excluded bool
+	}{
+		{[]string{"trace.log"}, false, true},
+		{[]string{"debug.log"}, false, false},
+		{[]string{"local"}, true, false},
+		{[]string{"src", "edit.swp"}, false, true},
+		{[]string{"src", "keep.swp"}, false, false},
+		{[]string{"cmd.txt"}, false, true},
+	}
+	for _, tc := range cases {
+		if res := m.Match(tc.path, tc.isDir); res != tc.excluded {
+			t.Errorf("%v: expected %v, found %v", tc.path, tc.excluded, res)
+		}
+	}
+}
+
+func TestLayeredMatcher_SetLayer_replaces(t *testing.T) {
+	m := gitignore.NewLayeredMatcher(gitignore.MatcherOptions{})
+	m.SetLayer(gitignore.InfoExcludeLayer, []gitignore.Pattern{gitignore.ParsePattern("*.log", nil)})
+	if !m.Match([]string{"trace.log"}, false) {
+		t.Errorf("expected a match, found mismatch")
+	}
+	m.SetLayer(gitignore.GitignoreLayer, []gitignore.Pattern{gitignore.ParsePattern("!trace.log", nil)})
+	if res := m.Result([]string{"trace.log"}, false); res != gitignore.Include {
+		t.Errorf("expected Include, found %v", res)
+	}
+	m.SetLayer(gitignore.GitignoreLayer, nil)
+	m.SetLayer(gitignore.InfoExcludeLayer, nil)
+	if m.Match([]string{"trace.log"}, false) {
+		t.Errorf("expected a mismatch, found a match")
+	}
+	if len(m.Layer(gitignore.InfoExcludeLayer)) != 0 {
+		t.Errorf("expected an empty layer")
+	}
+}
+
+func TestLayer_String(t *testing.T) {
+	if s := gitignore.InfoExcludeLayer.String(); s != "info/exclude" {
+		t.Errorf("expected info/exclude, found %v", s)
+	}
+}
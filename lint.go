@@ -0,0 +1,268 @@
+// Copyright (c) 2017. Oleg Sklyar & teris.io. All rights reserved.
+// See the LICENSE file in the project root for licensing information.
+
+package gitignore
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LintKind defines the kinds of problems found by Lint.
+type LintKind int
+
+const (
+	// LintInvalid marks a malformed pattern that never matches
+	LintInvalid LintKind = iota + 1
+	// LintDuplicate marks a pattern repeated later on with the same domain
+	LintDuplicate
+	// LintShadowed marks a pattern never deciding a match because a later pattern
+	// matches every path it matches
+	LintShadowed
+	// LintIneffectiveNegation marks a negated pattern re-including a path in a directory
+	// that is excluded, which git never looks into
+	LintIneffectiveNegation
+	// LintUnreachable marks a pattern of a gitignore file in an excluded directory,
+	// which git never reads
+	LintUnreachable
+)
+
+func (k LintKind) String() string {
+	switch k {
+	case LintInvalid:
+		return "invalid"
+	case LintDuplicate:
+		return "duplicate"
+	case LintShadowed:
+		return "shadowed"
+	case LintIneffectiveNegation:
+		return "ineffective negation"
+	case LintUnreachable:
+		return "unreachable"
+	}
+	return "LintKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// LintIssue describes a pattern that can never take effect.
+type LintIssue struct {
+	Kind LintKind
+	// Pattern is the pattern the issue is about
+	Pattern Pattern
+	// Cause is the pattern causing the issue, nil if none
+	Cause Pattern
+	// Source is the file the pattern was read from, empty if unknown
+	Source string
+	// Line is the 1-based line number of the pattern in the source, 0 if unknown
+	Line int
+	// Message describes the issue
+	Message string
+	// Fix suggests how to resolve the issue
+	Fix string
+}
+
+func (i LintIssue) String() string {
+	s := i.Kind.String() + ": " + i.Message
+	if loc := location(i.Pattern.Origin()); loc != "" {
+		s = loc + ": " + s
+	}
+	if i.Fix != "" {
+		s += " (" + i.Fix + ")"
+	}
+	return s
+}
+
+// Lint analyses the patterns, given in the order of increasing priority as for
+// NewMatcher, for patterns that can never take effect: malformed patterns, duplicates,
+// patterns shadowed by a later pattern matching everything they match, negations under
+// an excluded directory and patterns of gitignore files in excluded directories. The
+// analysis is conservative, a pattern is only reported when it provably never takes
+// effect. Patterns implemented outside of this package are not analysed. Issues are
+// listed in the order of the patterns.
+func Lint(patterns []Pattern) []LintIssue {
+	var issues []LintIssue
+	report := func(kind LintKind, pattern, cause Pattern, fix, format string, args ...interface{}) {
+		origin := pattern.Origin()
+		issues = append(issues, LintIssue{
+			Kind:    kind,
+			Pattern: pattern,
+			Cause:   cause,
+			Source:  origin.Source,
+			Line:    origin.Line,
+			Message: fmt.Sprintf(format, args...),
+			Fix:     fix,
+		})
+	}
+
+	m := NewMatcher(patterns)
+	unreachable := make(map[string]*Explanation)
+	for i, pattern := range patterns {
+		p, ok := pattern.(*ptrn)
+		if !ok {
+			continue
+		}
+
+		if len(p.domain) > 0 {
+			key := strings.Join(p.domain, "/")
+			e, seen := unreachable[key]
+			if !seen {
+				if ex := m.Explain(p.domain, true); ex.Result == Exclude {
+					e = &ex
+				}
+				unreachable[key] = e
+			}
+			if e != nil {
+				report(LintUnreachable, p, e.Pattern, "remove the pattern or re-include the directory",
+					"%q is never read as its directory %s/ is excluded by %s", p.text, key, describe(e.Pattern))
+				continue
+			}
+		}
+
+		if d := firstError(checkPattern(p.text, p)); d != nil {
+			report(LintInvalid, p, nil, "", "%q: %s", p.text, d.Message)
+			continue
+		}
+
+		if j, q := laterDuplicate(p, patterns[i+1:]); q != nil {
+			report(LintDuplicate, p, q, "remove the pattern", "%q is repeated by %s", p.text, describe(patterns[i+1+j]))
+			continue
+		}
+
+		if q := laterCover(p, patterns[i+1:]); q != nil {
+			fix := "remove the pattern"
+			if q.inclusion != p.inclusion {
+				fix = "remove the pattern or move it below " + describe(q)
+			}
+			report(LintShadowed, p, q, fix, "%q never decides a match as %s matches every path it matches", p.text, describe(q))
+			continue
+		}
+
+		if p.inclusion {
+			if path, ok := literalPath(p); ok {
+				for k := len(p.domain) + 1; k < len(path); k++ {
+					if e := m.Explain(path[:k], true); e.Result == Exclude {
+						parent := path[len(p.domain):k]
+						fix := fmt.Sprintf("re-include the directory with %q and exclude its content with %q before it",
+							anchoredPattern("!", parent, true), anchoredPattern("", parent, false)+"/*")
+						report(LintIneffectiveNegation, p, e.Pattern, fix,
+							"%q has no effect as its parent directory %s/ is excluded by %s",
+							p.text, strings.Join(path[:k], "/"), describe(e.Pattern))
+						break
+					}
+				}
+			}
+		}
+	}
+	return issues
+}
+
+// laterDuplicate returns the first of the patterns that is the same as p.
+func laterDuplicate(p *ptrn, patterns []Pattern) (int, *ptrn) {
+	for j, pattern := range patterns {
+		if q, ok := pattern.(*ptrn); ok && q.inclusion == p.inclusion && q.dirOnly == p.dirOnly &&
+			q.isGlob == p.isGlob && q.fold == p.fold && equalNames(q.domain, p.domain) && equalNames(q.pattern, p.pattern) {
+			return j, q
+		}
+	}
+	return -1, nil
+}
+
+// laterCover returns the last of the patterns that provably matches every path matched
+// by p. Only patterns matching literal names or paths are analysed.
+func laterCover(p *ptrn, patterns []Pattern) *ptrn {
+	for j := len(patterns) - 1; j >= 0; j-- {
+		q, ok := patterns[j].(*ptrn)
+		if !ok || p.fold && !q.fold {
+			continue
+		}
+		if p.isGlob {
+			if path, ok := literalPath(p); ok && q.match(path, true, matchExact) > NoMatch &&
+				(p.dirOnly || q.match(path, false, matchExact) > NoMatch) {
+				return q
+			}
+			continue
+		}
+		name, ok := literalName(p.pattern[0])
+		if !ok || q.isGlob || q.dirOnly && !p.dirOnly || len(q.domain) > len(p.domain) ||
+			!equalNames(q.domain, p.domain[:len(q.domain)]) {
+			continue
+		}
+		if matchName(q.pattern[0], name, q.fold) {
+			return q
+		}
+	}
+	return nil
+}
+
+// literalPath returns the only path matched by a glob pattern without wildcards.
+func literalPath(p *ptrn) ([]string, bool) {
+	if !p.isGlob {
+		return nil, false
+	}
+	path := append([]string(nil), p.domain...)
+	for _, segment := range p.pattern {
+		if segment == "" {
+			continue
+		}
+		name, ok := literalName(segment)
+		if !ok {
+			return nil, false
+		}
+		path = append(path, name)
+	}
+	return path, len(path) > len(p.domain)
+}
+
+// literalName resolves the escapes of a pattern segment without wildcards.
+func literalName(segment string) (string, bool) {
+	var b strings.Builder
+	for i := 0; i < len(segment); i++ {
+		switch c := segment[i]; c {
+		case '*', '?', '[':
+			return "", false
+		case '\\':
+			if i++; i == len(segment) {
+				return "", false
+			}
+		}
+		b.WriteByte(segment[i])
+	}
+	return b.String(), segment != ""
+}
+
+func equalNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// describe refers to a pattern with its text and location.
+func describe(pattern Pattern) string {
+	origin := pattern.Origin()
+	text := origin.Raw
+	if p, ok := pattern.(*ptrn); ok {
+		text = p.text
+	}
+	if loc := location(origin); loc != "" {
+		return strconv.Quote(text) + " at " + loc
+	}
+	return strconv.Quote(text)
+}
+
+// location formats the source and the line of the origin as "source:line" leaving out
+// the parts that are unknown.
+func location(origin Origin) string {
+	switch {
+	case origin.Source != "" && origin.Line > 0:
+		return origin.Source + ":" + strconv.Itoa(origin.Line)
+	case origin.Line > 0:
+		return "line " + strconv.Itoa(origin.Line)
+	}
+	return origin.Source
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2017. Oleg Sklyar & teris.io. All rights reserved.
+// See the LICENSE file in the project root for licensing information.
+
+package gitignore_test
+
+import (
+	"github.com/teris-io/gitignore"
+	"strings"
+	"testing"
+)
+
+func lintPatterns(t *testing.T, source, content string, domain []string) []gitignore.Pattern {
+	options := gitignore.ParseOptions{Origin: gitignore.Origin{Source: source}}
+	patterns, _, err := gitignore.ParseReader(strings.NewReader(content), domain, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return patterns
+}
+
+func TestLint(t *testing.T) {
+	patterns := lintPatterns(t, ".gitignore", strings.Join([]string{
+		"*.log",      // 1: duplicate of 4
+		"debug.txt",  // 2: shadowed by 5
+		"/debug.txt", // 3: shadowed by 5
+		"*.log",      // 4
+		"*.txt",      // 5
+		"a/",         // 6
+		"!/a/b/keep", // 7: ineffective, a/ is excluded
+		"x/[yz",      // 8: invalid
+		"vendor/",    // 9
+		"build/",     // 10: not shadowed by a later file only pattern
+		"/build",     // 11
+	}, "\n"), nil)
+	patterns = append(patterns, lintPatterns(t, "vendor/.gitignore", "!lib/\n", []string{"vendor"})...)
+
+	expected := []struct {
+		kind gitignore.LintKind
+		line int
+	}{
+		{gitignore.LintDuplicate, 1},
+		{gitignore.LintShadowed, 2},
+		{gitignore.LintShadowed, 3},
+		{gitignore.LintIneffectiveNegation, 7},
+		{gitignore.LintInvalid, 8},
+		{gitignore.LintUnreachable, 1},
+	}
+	issues := gitignore.Lint(patterns)
+	if len(issues) != len(expected) {
+		for _, issue := range issues {
+			t.Log(issue)
+		}
+		t.Fatalf("expected %d issues, found %d", len(expected), len(issues))
+	}
+	for i, e := range expected {
+		if issues[i].Kind != e.kind || issues[i].Line != e.line {
+			t.Errorf("expected %v at line %d, found %v", e.kind, e.line, issues[i])
+		}
+	}
+	if issue := issues[3]; issue.Cause != patterns[5] {
+		t.Errorf("expected the cause to be a/, found %v", issue.Cause)
+	}
+	if fix := issues[3].Fix; !strings.Contains(fix, `"!/a/"`) || !strings.Contains(fix, `"/a/*"`) {
+		t.Errorf("expected a fix re-including a/, found %q", fix)
+	}
+	if s := issues[5].String(); !strings.HasPrefix(s, "vendor/.gitignore:1: unreachable: ") || !strings.Contains(s, `"vendor/" at .gitignore:9`) {
+		t.Errorf("expected the issue with provenance, found %q", s)
+	}
+}
+
+func TestLint_clean(t *testing.T) {
+	patterns := lintPatterns(t, ".gitignore", "*.log\n!keep.log\n/build/\n!/a/\n/a/*\n!/a/b/\n", nil)
+	if issues := gitignore.Lint(patterns); len(issues) != 0 {
+		t.Errorf("expected no issues, found %v", issues)
+	}
+}
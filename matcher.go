@@ -9,8 +9,59 @@ package gitignore
 // Matcher defines a global multi-pattern matcher for gitignore patterns
 type Matcher interface {
 	// Match matches patterns in the order of priorities. As soon as an inclusion or
-	// exclusion is found, not further matching is performed.
+	// exclusion is found, not further matching is performed. A path is excluded when
+	// any of its parent directories is excluded, irrespective of later inclusions.
 	Match(path []string, isDir bool) bool
+	// Result matches the path as Match does, but tells an explicit inclusion by a
+	// negated pattern apart from no pattern matching at all.
+	Result(path []string, isDir bool) MatchResult
+	// Explain matches the path as Match does and reports the pattern that decided the
+	// outcome, as git check-ignore -v does.
+	Explain(path []string, isDir bool) Explanation
+	// MatchPath matches a path given as a string relative to the root of the tree, see
+	// SplitPath, and fails for paths outside of the root.
+	MatchPath(path string, isDir bool) (bool, error)
+	// MatchFile matches a file of the file system tree rooted at root, given relative to
+	// the root or as an absolute path within it, looking up whether it is a directory.
+	MatchFile(root, path string) (bool, error)
+	// MatchString matches a clean slash separated path relative to the root of the tree
+	// without allocating for paths up to 32 components deep. Empty components are
+	// skipped, but "." and ".." are not resolved, see MatchPath for that. Patterns
+	// implemented outside of this package are given a copy of the path, made once per
+	// call, as they may keep it.
+	MatchString(path string, isDir bool) bool
+	// MatchBytes matches a path as MatchString does without copying the bytes.
+	MatchBytes(path []byte, isDir bool) bool
+}
+
+// Explanation describes why a path is excluded, included or not matched at all.
+type Explanation struct {
+	// Result is the outcome of the match
+	Result MatchResult
+	// Pattern is the deciding pattern, nil if no pattern matched
+	Pattern Pattern
+	// Source is the file the deciding pattern was read from, empty if unknown
+	Source string
+	// Line is the 1-based line number of the deciding pattern in the source, 0 if unknown
+	Line int
+	// Text is the original text of the deciding pattern
+	Text string
+	// Negated tells whether the deciding pattern is a negated one
+	Negated bool
+	// Parent is set to the excluded parent directory when the path is excluded because
+	// of it rather than by a pattern matching the path itself
+	Parent []string
+}
+
+// MatcherOptions defines optional settings of a matcher.
+type MatcherOptions struct {
+	// Lenient disables the check of parent directories. Then, as in earlier versions of
+	// this package, a path is re-included by a matching negated pattern even when its
+	// parent directory is excluded, while git never looks into the excluded directory.
+	Lenient bool
+	// CaseInsensitive matches all patterns irrespective of case, as git does with
+	// core.ignoreCase set, see ParseOptions.
+	CaseInsensitive bool
 }
 
 // NewMatcher constructs a new global matcher. Patterns must be given in the order of
@@ -18,19 +69,108 @@ type Matcher interface {
 // the repo .gitignore, then content of .gitignore down the path or the repo and then
 // the content command line arguments.
 func NewMatcher(patterns []Pattern) Matcher {
-	return &matcher{patterns}
+	return NewMatcherWithOptions(patterns, MatcherOptions{})
+}
+
+// NewMatcherWithOptions constructs a new global matcher with the given options. Patterns
+// must be given in the order of increasing priority as for NewMatcher.
+func NewMatcherWithOptions(patterns []Pattern, options MatcherOptions) Matcher {
+	return newMatcher(patterns, options)
 }
 
 type matcher struct {
 	patterns []Pattern
+	options  MatcherOptions
+	// foreign tells whether any of the patterns is implemented outside of this package
+	foreign bool
+}
+
+func newMatcher(patterns []Pattern, options MatcherOptions) *matcher {
+	return &matcher{patterns: patterns, options: options, foreign: hasForeign(patterns)}
 }
 
 func (m *matcher) Match(path []string, isDir bool) bool {
-	n := len(m.patterns)
-	for i := n - 1; i >= 0; i-- {
-		if match := m.patterns[i].Match(path, isDir); match > NoMatch {
-			return match == Exclude
+	return m.Result(path, isDir) == Exclude
+}
+
+func (m *matcher) MatchPath(path string, isDir bool) (bool, error) {
+	return matchPath(m, path, isDir)
+}
+
+func (m *matcher) MatchFile(root, path string) (bool, error) {
+	return matchFile(m, root, path)
+}
+
+func (m *matcher) MatchString(path string, isDir bool) bool {
+	var buf [maxStackDepth]string
+	split := splitString(buf[:0], path)
+	return m.explain(split, heapPath(split, m.foreign), isDir).Result == Exclude
+}
+
+func (m *matcher) MatchBytes(path []byte, isDir bool) bool {
+	return m.MatchString(bytesToString(path), isDir)
+}
+
+func (m *matcher) Result(path []string, isDir bool) MatchResult {
+	return m.Explain(path, isDir).Result
+}
+
+func (m *matcher) Explain(path []string, isDir bool) Explanation {
+	return m.explain(path, path, isDir)
+}
+
+// explain matches the path as Explain does. Patterns implemented outside of this package
+// are given heap instead of the path, see matchPattern.
+func (m *matcher) explain(path, heap []string, isDir bool) Explanation {
+	var flags matchFlags
+	if m.options.CaseInsensitive {
+		flags |= matchFold
+	}
+	if m.options.Lenient {
+		n := len(m.patterns)
+		for i := n - 1; i >= 0; i-- {
+			if match := matchPattern(m.patterns[i], path, heap, isDir, flags); match > NoMatch {
+				return explain(m.patterns[i], match, nil)
+			}
+		}
+		return Explanation{}
+	}
+	for i := 1; i < len(path); i++ {
+		if j, match := matchLast(m.patterns, path[:i], heap, true, flags); match == Exclude {
+			return explain(m.patterns[j], match, path[:i])
 		}
 	}
-	return false
+	if j, match := matchLast(m.patterns, path, heap, isDir, flags); match > NoMatch {
+		return explain(m.patterns[j], match, nil)
+	}
+	return Explanation{}
+}
+
+// matchLast matches the path itself, but not its parent directories, against the
+// patterns in the order of decreasing priority and returns the first outcome found
+// along with the index of the pattern, or -1 if none matched.
+func matchLast(patterns []Pattern, path, heap []string, isDir bool, flags matchFlags) (int, MatchResult) {
+	for i := len(patterns) - 1; i >= 0; i-- {
+		if match := matchPattern(patterns[i], path, heap, isDir, flags|matchExact); match > NoMatch {
+			return i, match
+		}
+	}
+	return -1, NoMatch
+}
+
+func explain(pattern Pattern, result MatchResult, parent []string) Explanation {
+	origin := pattern.Origin()
+	e := Explanation{
+		Result:  result,
+		Pattern: pattern,
+		Source:  origin.Source,
+		Line:    origin.Line,
+		Text:    origin.Raw,
+		Negated: result == Include,
+		Parent:  parent,
+	}
+	if p, ok := pattern.(*ptrn); ok {
+		e.Text, e.Negated = p.text, p.inclusion
+	}
+	return e
 }
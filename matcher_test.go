@@ -5,7 +5,9 @@ package gitignore_test
 
 import (
 	"github.com/teris-io/gitignore"
+	"reflect"
 	"testing"
+	"testing/fstest"
 )
 
 func TestMatcher_Match(t *testing.T) {
@@ -21,3 +23,136 @@ func TestMatcher_Match(t *testing.T) {
 		t.Errorf("expected a mismatch, found a match")
 	}
 }
+
+func TestMatcher_Match_excludedParent(t *testing.T) {
+	patterns := []gitignore.Pattern{
+		gitignore.ParsePattern("build/", nil),
+		gitignore.ParsePattern("!build/keep.txt", nil),
+	}
+	matcher := gitignore.NewMatcher(patterns)
+	if !matcher.Match([]string{"build", "keep.txt"}, false) {
+		t.Errorf("expected a match, found mismatch")
+	}
+	lenient := gitignore.NewMatcherWithOptions(patterns, gitignore.MatcherOptions{Lenient: true})
+	if lenient.Match([]string{"build", "keep.txt"}, false) {
+		t.Errorf("expected a mismatch, found a match")
+	}
+}
+
+func TestMatcher_Match_includedParent(t *testing.T) {
+	patterns := []gitignore.Pattern{
+		gitignore.ParsePattern("*", nil),
+		gitignore.ParsePattern("!*/", nil),
+		gitignore.ParsePattern("!*.go", nil),
+	}
+	matcher := gitignore.NewMatcher(patterns)
+	if matcher.Match([]string{"src", "main.go"}, false) {
+		t.Errorf("expected a mismatch, found a match")
+	}
+	if !matcher.Match([]string{"src", "README"}, false) {
+		t.Errorf("expected a match, found mismatch")
+	}
+	if matcher.Match([]string{"src", "pkg"}, true) {
+		t.Errorf("expected a mismatch, found a match")
+	}
+}
+
+func TestMatcher_Match_reincludedParent(t *testing.T) {
+	patterns := []gitignore.Pattern{
+		gitignore.ParsePattern("/build/*", nil),
+		gitignore.ParsePattern("!/build/keep/", nil),
+	}
+	matcher := gitignore.NewMatcher(patterns)
+	if matcher.Match([]string{"build", "keep", "file.txt"}, false) {
+		t.Errorf("expected a mismatch, found a match")
+	}
+	if !matcher.Match([]string{"build", "other", "file.txt"}, false) {
+		t.Errorf("expected a match, found mismatch")
+	}
+}
+
+func TestMatcher_Match_caseInsensitive(t *testing.T) {
+	patterns := []gitignore.Pattern{
+		gitignore.ParsePattern("build/", nil),
+		gitignore.ParsePattern("!Build/Keep.txt", nil),
+		gitignore.ParsePattern("*.o", []string{"src"}),
+	}
+	matcher := gitignore.NewMatcherWithOptions(patterns, gitignore.MatcherOptions{CaseInsensitive: true})
+	if !matcher.Match([]string{"BUILD", "keep.txt"}, false) {
+		t.Errorf("expected a match, found mismatch")
+	}
+	if !matcher.Match([]string{"Src", "main.O"}, false) {
+		t.Errorf("expected a match, found mismatch")
+	}
+	lenient := gitignore.NewMatcherWithOptions(patterns, gitignore.MatcherOptions{CaseInsensitive: true, Lenient: true})
+	if lenient.Match([]string{"BUILD", "keep.txt"}, false) {
+		t.Errorf("expected a mismatch, found a match")
+	}
+	if gitignore.NewMatcher(patterns).Match([]string{"Src", "main.O"}, false) {
+		t.Errorf("expected a mismatch, found a match")
+	}
+}
+
+func TestMatcher_Explain(t *testing.T) {
+	fsys := fstest.MapFS{
+		".gitignore":       {Data: []byte("# build output\nbuild/\n*.log\n")},
+		"src/.gitignore":   {Data: []byte("\n!keep.log\n")},
+		"build/keep/x.txt": {Data: []byte("\n")},
+	}
+	patterns, err := gitignore.ReadPatterns(gitignore.NewFSDir(fsys))
+	if err != nil {
+		t.Fatalf("no error expected, found %v", err)
+	}
+	matcher := gitignore.NewMatcher(patterns)
+
+	e := matcher.Explain([]string{"src", "keep.log"}, false)
+	if e.Result != gitignore.Include || e.Source != "src/.gitignore" || e.Line != 2 || e.Text != "!keep.log" || !e.Negated || e.Parent != nil {
+		t.Errorf("unexpected explanation %+v", e)
+	}
+	e = matcher.Explain([]string{"debug.log"}, false)
+	if e.Result != gitignore.Exclude || e.Source != ".gitignore" || e.Line != 3 || e.Text != "*.log" || e.Negated {
+		t.Errorf("unexpected explanation %+v", e)
+	}
+	e = matcher.Explain([]string{"build", "keep", "x.txt"}, false)
+	if e.Result != gitignore.Exclude || e.Line != 2 || e.Text != "build/" || !reflect.DeepEqual(e.Parent, []string{"build"}) {
+		t.Errorf("unexpected explanation %+v", e)
+	}
+	e = matcher.Explain([]string{"main.go"}, false)
+	if e.Result != gitignore.NoMatch || e.Pattern != nil {
+		t.Errorf("unexpected explanation %+v", e)
+	}
+}
+
+func TestMatcher_Explain_parsedPattern(t *testing.T) {
+	pattern := gitignore.ParsePattern("*.o", nil)
+	e := gitignore.NewMatcher([]gitignore.Pattern{pattern}).Explain([]string{"main.o"}, false)
+	if e.Pattern != pattern || e.Text != "*.o" || e.Source != "" || e.Line != 0 {
+		t.Errorf("unexpected explanation %+v", e)
+	}
+}
+
+func TestMatcher_Result(t *testing.T) {
+	patterns := []gitignore.Pattern{
+		gitignore.ParsePattern("*.log", nil),
+		gitignore.ParsePattern("!keep.log", nil),
+		gitignore.ParsePattern("build/", nil),
+	}
+	matcher := gitignore.NewMatcher(patterns)
+	cases := []struct {
+		path     []string
+		expected gitignore.MatchResult
+	}{
+		{[]string{"debug.log"}, gitignore.Exclude},
+		{[]string{"keep.log"}, gitignore.Include},
+		{[]string{"main.go"}, gitignore.NoMatch},
+		{[]string{"build", "keep.log"}, gitignore.Exclude},
+	}
+	for _, tc := range cases {
+		if res := matcher.Result(tc.path, false); res != tc.expected {
+			t.Errorf("%v: expected %v, found %v", tc.path, tc.expected, res)
+		}
+		if excluded := matcher.Match(tc.path, false); excluded != (tc.expected == gitignore.Exclude) {
+			t.Errorf("%v: expected Match to agree with %v", tc.path, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2017. Oleg Sklyar & teris.io. All rights reserved.
+// See the LICENSE file in the project root for licensing information.
+
+package gitignore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// OSDirOptions defines optional settings of a Dir backed by the OS filesystem.
+type OSDirOptions struct {
+	// FollowSymlinks makes symbolic links to directories traversed as sub-directories
+	// and symbolically linked .gitignore files read. By default both are skipped as git
+	// does not follow them. Links leading back into a directory on the current path are
+	// never followed.
+	FollowSymlinks bool
+}
+
+// NewOSDir constructs a Dir over the OS filesystem rooted at the given directory,
+// normally the top of the work tree. Directories named .git are not traversed, nor are
+// sub-directories containing a .git directory or file as these mark nested repositories,
+// submodules and worktrees. Symbolic links are not followed.
+func NewOSDir(root string) Dir {
+	return NewOSDirWithOptions(root, OSDirOptions{})
+}
+
+// NewOSDirWithOptions constructs a Dir over the OS filesystem rooted at the given
+// directory with the given options.
+func NewOSDirWithOptions(root string, options OSDirOptions) Dir {
+	return &osDir{root: root, options: options}
+}
+
+type osDir struct {
+	root    string
+	path    []string
+	options OSDirOptions
+	// parents holds the directories on the way from the root for loop detection.
+	parents []os.FileInfo
+}
+
+func (d *osDir) Path() []string {
+	return d.path
+}
+
+func (d *osDir) ReadFile(name string) ([]byte, error) {
+	filename := filepath.Join(d.filepath(), name)
+	if !d.options.FollowSymlinks {
+		if fi, err := os.Lstat(filename); err != nil {
+			return nil, err
+		} else if fi.Mode()&os.ModeSymlink != 0 {
+			return nil, fmt.Errorf("%s is a symbolic link", filename)
+		}
+	}
+	return os.ReadFile(filename)
+}
+
+func (d *osDir) Subdirs() ([]Dir, error) {
+	dirname := d.filepath()
+	self, err := os.Stat(dirname)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dirname)
+	if err != nil {
+		return nil, err
+	}
+	parents := append(d.parents[:len(d.parents):len(d.parents)], self)
+
+	var subdirs []Dir
+	for _, entry := range entries {
+		if entry.Name() == ".git" {
+			continue
+		}
+		subdirname := filepath.Join(dirname, entry.Name())
+		if entry.Type()&os.ModeSymlink != 0 {
+			if !d.options.FollowSymlinks {
+				continue
+			}
+			fi, err := os.Stat(subdirname)
+			if err != nil || !fi.IsDir() || isParent(parents, fi) {
+				continue
+			}
+		} else if !entry.IsDir() {
+			continue
+		}
+		if _, err := os.Lstat(filepath.Join(subdirname, ".git")); err == nil {
+			continue
+		}
+		path := append(d.path[:len(d.path):len(d.path)], entry.Name())
+		subdirs = append(subdirs, &osDir{root: d.root, path: path, options: d.options, parents: parents})
+	}
+	return subdirs, nil
+}
+
+func (d *osDir) filepath() string {
+	return filepath.Join(append([]string{d.root}, d.path...)...)
+}
+
+func isParent(parents []os.FileInfo, fi os.FileInfo) bool {
+	for _, parent := range parents {
+		if os.SameFile(parent, fi) {
+			return true
+		}
+	}
+	return false
+}
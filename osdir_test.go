@@ -0,0 +1,119 @@
+// Copyright (c) 2017. Oleg Sklyar & teris.io. All rights reserved.
+// See the LICENSE file in the project root for licensing information.
+
+package gitignore_test
+
+import (
+	"github.com/teris-io/gitignore"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t testing.TB, root, name, content string) {
+	filename := filepath.Join(root, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func symlink(t testing.TB, oldname, newname string) {
+	if err := os.Symlink(oldname, newname); err != nil {
+		t.Skipf("symbolic links not supported: %v", err)
+	}
+}
+
+func TestOSDir_ReadPatterns(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, ".gitignore", "*.log\n")
+	writeFile(t, root, "src/.gitignore", "!keep.log\n")
+	writeFile(t, root, ".git/.gitignore", "git\n")
+	writeFile(t, root, "module/.git", "gitdir: ../.git/modules/module\n")
+	writeFile(t, root, "module/.gitignore", "module\n")
+	writeFile(t, root, "nested/.git/HEAD", "ref: refs/heads/master\n")
+	writeFile(t, root, "nested/.gitignore", "nested\n")
+
+	patterns, err := gitignore.ReadPatterns(gitignore.NewOSDir(root))
+	if err != nil {
+		t.Fatalf("no error expected, found %v", err)
+	}
+	if len(patterns) != 2 {
+		t.Fatalf("expected 2 patterns, found %v", len(patterns))
+	}
+	if res := patterns[1].Match([]string{"src", "keep.log"}, false); res != gitignore.Include {
+		t.Errorf("expected Include, found %v", res)
+	}
+}
+
+func TestOSDir_Path(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "a/b/file", "")
+	subdirs, err := gitignore.NewOSDir(root).Subdirs()
+	if err != nil || len(subdirs) != 1 {
+		t.Fatalf("expected 1 sub-directory, found %v, %v", len(subdirs), err)
+	}
+	subdirs, err = subdirs[0].Subdirs()
+	if err != nil || len(subdirs) != 1 {
+		t.Fatalf("expected 1 sub-directory, found %v, %v", len(subdirs), err)
+	}
+	if path := subdirs[0].Path(); len(path) != 2 || path[0] != "a" || path[1] != "b" {
+		t.Errorf("expected [a b], found %v", path)
+	}
+}
+
+func TestOSDir_symlinks_notFollowed(t *testing.T) {
+	root := t.TempDir()
+	target := t.TempDir()
+	writeFile(t, target, ".gitignore", "target\n")
+	writeFile(t, target, "ignore", "linked\n")
+	symlink(t, target, filepath.Join(root, "link"))
+	symlink(t, filepath.Join(target, "ignore"), filepath.Join(root, ".gitignore"))
+
+	patterns, err := gitignore.ReadPatterns(gitignore.NewOSDir(root))
+	if err != nil {
+		t.Fatalf("no error expected, found %v", err)
+	}
+	if len(patterns) != 0 {
+		t.Errorf("expected no patterns, found %v", len(patterns))
+	}
+}
+
+func TestOSDir_symlinks_followed(t *testing.T) {
+	root := t.TempDir()
+	target := t.TempDir()
+	writeFile(t, target, ".gitignore", "target\n")
+	writeFile(t, target, "ignore", "linked\n")
+	symlink(t, target, filepath.Join(root, "link"))
+	symlink(t, filepath.Join(target, "ignore"), filepath.Join(root, ".gitignore"))
+
+	dir := gitignore.NewOSDirWithOptions(root, gitignore.OSDirOptions{FollowSymlinks: true})
+	patterns, err := gitignore.ReadPatterns(dir)
+	if err != nil {
+		t.Fatalf("no error expected, found %v", err)
+	}
+	if len(patterns) != 2 {
+		t.Errorf("expected 2 patterns, found %v", len(patterns))
+	}
+}
+
+func TestOSDir_symlinks_loop(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "a/.gitignore", "a\n")
+	writeFile(t, root, "b/.gitignore", "b\n")
+	symlink(t, filepath.Join(root, "b"), filepath.Join(root, "a", "link"))
+	symlink(t, filepath.Join(root, "a"), filepath.Join(root, "b", "link"))
+	symlink(t, root, filepath.Join(root, "root"))
+
+	dir := gitignore.NewOSDirWithOptions(root, gitignore.OSDirOptions{FollowSymlinks: true})
+	patterns, err := gitignore.ReadPatterns(dir)
+	if err != nil {
+		t.Fatalf("no error expected, found %v", err)
+	}
+	// a, a/link (b), b, b/link (a)
+	if len(patterns) != 4 {
+		t.Errorf("expected 4 patterns, found %v", len(patterns))
+	}
+}
@@ -0,0 +1,420 @@
+// Copyright (c) 2017. Oleg Sklyar & teris.io. All rights reserved.
+// See the LICENSE file in the project root for licensing information.
+
+package gitignore
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// ParallelWalkOptions defines optional settings of ParallelWalk.
+type ParallelWalkOptions struct {
+	// Workers is the number of goroutines reading directories. Defaults to GOMAXPROCS.
+	Workers int
+	// Ordered makes fn called from a single goroutine in the same lexical order as by
+	// Walk. Directories are still read concurrently and ahead of the calls, see
+	// ReadAhead. Otherwise fn is called concurrently from the workers as soon as entries
+	// are read, so it must be safe for concurrent use, and only the entries of one
+	// directory keep their order.
+	Ordered bool
+	// ReadAhead limits the number of directories read ahead of the calls to fn in the
+	// ordered mode, and so the memory held when fn is slower than reading the tree.
+	// Defaults to 64 per worker.
+	ReadAhead int
+}
+
+// ParallelWalk walks the file tree rooted at root like Walk does, but reads directories
+// concurrently on a pool of workers. Directories inherit the gitignore patterns of their
+// parents without copying them. The walk stops with the context error when the context
+// is cancelled. Returning filepath.SkipDir from fn skips the directory, or the remaining
+// entries of the directory containing the file, and returning filepath.SkipAll or any
+// other error stops the walk; the error, other than SkipAll, is then returned.
+func ParallelWalk(ctx context.Context, root string, fn WalkFunc, options ParallelWalkOptions) error {
+	info, err := os.Lstat(root)
+	if err != nil {
+		err = fn(root, nil, NoMatch, err)
+	} else if err = fn(root, fs.FileInfoToDirEntry(info), NoMatch, nil); err == nil && info.IsDir() {
+		err = parallelWalk(ctx, root, fs.FileInfoToDirEntry(info), fn, options)
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+func parallelWalk(ctx context.Context, root string, d fs.DirEntry, fn WalkFunc, options ParallelWalkOptions) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	workers := options.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	readAhead := options.ReadAhead
+	if readAhead <= 0 {
+		readAhead = 64 * workers
+	}
+	w := &parallelWalker{
+		walker: walker{root: root, fn: fn},
+		ctx:    ctx,
+		cancel: cancel,
+		pool:   newWalkPool(ctx, readAhead),
+	}
+	task := &dirTask{name: root, d: d, result: NoMatch}
+	if options.Ordered {
+		task.out = &dirFuture{task: task, done: make(chan struct{})}
+	}
+	w.pool.push(task)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task, ok := w.pool.pop(); ok; task, ok = w.pool.pop() {
+				if task.out != nil {
+					w.prefetch(task)
+				} else {
+					w.visit(task)
+				}
+				w.pool.done()
+			}
+		}()
+	}
+
+	if options.Ordered {
+		err := w.emit(task.name, task.d, task.result, task.out)
+		cancel()
+		wg.Wait()
+		return err
+	}
+	wg.Wait()
+	if w.stopped {
+		return w.err
+	}
+	return ctx.Err()
+}
+
+type parallelWalker struct {
+	walker
+	ctx     context.Context
+	cancel  context.CancelFunc
+	pool    *walkPool
+	once    sync.Once
+	err     error
+	stopped bool
+}
+
+// dirTask is a directory to be read by a worker.
+type dirTask struct {
+	name   string
+	path   []string
+	d      fs.DirEntry
+	result MatchResult
+	parent *layer
+	// out receives the entries read in the ordered mode
+	out *dirFuture
+}
+
+// dirFuture holds the entries of a directory read ahead in the ordered mode.
+type dirFuture struct {
+	task    *dirTask
+	state   atomic.Int32
+	done    chan struct{}
+	entries []walkEntry
+	err     error
+}
+
+// States of a dirFuture.
+const (
+	// futurePending marks a directory that has not been read yet
+	futurePending int32 = iota
+	// futureAhead marks a directory read by a worker, holding a read-ahead slot
+	futureAhead
+	// futureClaimed marks a directory read or discarded by emit
+	futureClaimed
+	// futureReleased marks a directory read by a worker that has freed its slot
+	futureReleased
+)
+
+type walkEntry struct {
+	name   string
+	path   []string
+	d      fs.DirEntry
+	result MatchResult
+	// child is set for directories that are to be entered in the ordered mode
+	child *dirFuture
+}
+
+// stop stops the walk with the given error unless it has already been stopped.
+func (w *parallelWalker) stop(err error) {
+	w.once.Do(func() {
+		if err != filepath.SkipAll {
+			w.err = err
+		}
+		w.stopped = true
+		w.cancel()
+	})
+}
+
+func (w *parallelWalker) read(task *dirTask) (l *layer, entries []walkEntry, err error) {
+	l = task.parent.load(&osDir{root: w.root, path: task.path})
+	des, err := os.ReadDir(task.name)
+	for _, de := range des {
+		if de.Name() == ".git" {
+			continue
+		}
+		path := append(task.path[:len(task.path):len(task.path)], de.Name())
+		entries = append(entries, walkEntry{
+			name:   filepath.Join(task.name, de.Name()),
+			path:   path,
+			d:      de,
+			result: l.match(path, de.IsDir()),
+		})
+	}
+	return l, entries, err
+}
+
+func (e *walkEntry) enter() bool {
+	return e.d.IsDir() && e.result != Exclude && !isRepository(e.name)
+}
+
+// visit reads the directory and calls fn for its entries in the unordered mode.
+func (w *parallelWalker) visit(task *dirTask) {
+	l, entries, err := w.read(task)
+	if err != nil {
+		if err = w.fn(task.name, task.d, task.result, err); err != nil {
+			if err != filepath.SkipDir {
+				w.stop(err)
+			}
+			return
+		}
+	}
+	for _, e := range entries {
+		if w.ctx.Err() != nil {
+			return
+		}
+		if err := w.fn(e.name, e.d, e.result, nil); err == filepath.SkipDir {
+			if e.d.IsDir() {
+				continue
+			}
+			return
+		} else if err != nil {
+			w.stop(err)
+			return
+		}
+		if e.enter() {
+			w.pool.push(&dirTask{name: e.name, path: e.path, d: e.d, result: e.result, parent: l})
+		}
+	}
+}
+
+// prefetch reads the directory ahead of the calls to fn in the ordered mode unless emit
+// has claimed it. Without a free read-ahead slot the task is parked in the pool until
+// one is released.
+func (w *parallelWalker) prefetch(task *dirTask) {
+	if task.out.state.Load() != futurePending || !w.pool.reserve(task) {
+		return
+	}
+	if !task.out.state.CompareAndSwap(futurePending, futureAhead) {
+		w.pool.release()
+		return
+	}
+	w.fill(task)
+}
+
+// fill reads the directory of the task into its future and queues its sub-directories
+// to be read ahead.
+func (w *parallelWalker) fill(task *dirTask) {
+	defer close(task.out.done)
+	l, entries, err := w.read(task)
+	task.out.entries, task.out.err = entries, err
+	for i := len(entries) - 1; i >= 0; i-- {
+		if e := &entries[i]; e.enter() {
+			child := &dirTask{name: e.name, path: e.path, d: e.d, result: e.result, parent: l}
+			child.out = &dirFuture{task: child, done: make(chan struct{})}
+			e.child = child.out
+			w.pool.push(child)
+		}
+	}
+}
+
+// emit calls fn for the entries of a prefetched directory and, recursively, of its
+// sub-directories in the lexical order.
+func (w *parallelWalker) emit(name string, d fs.DirEntry, result MatchResult, dir *dirFuture) error {
+	if err := w.claim(dir); err != nil {
+		return err
+	}
+	// the directory is no longer ahead of the calls
+	w.release(dir)
+	entries := dir.entries
+	defer func() { dir.task, dir.entries = nil, nil }()
+	if dir.err != nil {
+		if err := w.fn(name, d, result, dir.err); err != nil {
+			w.discard(entries)
+			if err == filepath.SkipDir {
+				return nil
+			}
+			return err
+		}
+	}
+	for i, e := range entries {
+		err := w.fn(e.name, e.d, e.result, nil)
+		if err == filepath.SkipDir && !e.d.IsDir() {
+			w.discard(entries[i:])
+			return nil
+		} else if err == filepath.SkipDir {
+			w.discard(entries[i : i+1])
+			continue
+		} else if err != nil {
+			return err
+		}
+		if e.child != nil {
+			if err := w.emit(e.name, e.d, e.result, e.child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// claim waits for the directory to be read, reading it right away unless a worker has
+// started to, so that emit never waits for a task parked in the pool.
+func (w *parallelWalker) claim(dir *dirFuture) error {
+	if err := w.ctx.Err(); err != nil {
+		return err
+	}
+	if dir.state.CompareAndSwap(futurePending, futureClaimed) {
+		w.fill(dir.task)
+		return nil
+	}
+	select {
+	case <-dir.done:
+		return nil
+	case <-w.ctx.Done():
+		return w.ctx.Err()
+	}
+}
+
+// discard drops the directories of the entries that are not entered along with all that
+// has been read ahead under them.
+func (w *parallelWalker) discard(entries []walkEntry) {
+	for _, e := range entries {
+		dir := e.child
+		if dir == nil {
+			continue
+		}
+		if dir.state.CompareAndSwap(futurePending, futureClaimed) {
+			close(dir.done)
+			continue
+		}
+		select {
+		case <-dir.done:
+		case <-w.ctx.Done():
+			return
+		}
+		w.release(dir)
+		w.discard(dir.entries)
+		dir.task, dir.entries = nil, nil
+	}
+}
+
+// release frees the read-ahead slot of a directory read by a worker.
+func (w *parallelWalker) release(dir *dirFuture) {
+	if dir.state.CompareAndSwap(futureAhead, futureReleased) {
+		w.pool.release()
+	}
+}
+
+// walkPool is an unbounded last-in first-out queue of directories shared by the
+// workers. Popping blocks until a task is available, or returns false when all tasks
+// have been processed or the context is cancelled. In the ordered mode the pool also
+// hands out the read-ahead slots.
+type walkPool struct {
+	ctx     context.Context
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   []*dirTask
+	pending int
+	// ahead counts the slots taken out of maxAhead, parked lists the tasks waiting for one
+	ahead    int
+	maxAhead int
+	parked   []*dirTask
+}
+
+func newWalkPool(ctx context.Context, maxAhead int) *walkPool {
+	p := &walkPool{ctx: ctx, maxAhead: maxAhead}
+	p.cond = sync.NewCond(&p.mu)
+	go func() {
+		<-ctx.Done()
+		p.mu.Lock()
+		p.cond.Broadcast()
+		p.mu.Unlock()
+	}()
+	return p
+}
+
+func (p *walkPool) push(task *dirTask) {
+	p.mu.Lock()
+	p.queue = append(p.queue, task)
+	p.pending++
+	p.cond.Signal()
+	p.mu.Unlock()
+}
+
+func (p *walkPool) pop() (*dirTask, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for len(p.queue) == 0 && p.pending > 0 && p.ctx.Err() == nil {
+		p.cond.Wait()
+	}
+	if len(p.queue) == 0 || p.ctx.Err() != nil {
+		return nil, false
+	}
+	task := p.queue[len(p.queue)-1]
+	p.queue[len(p.queue)-1] = nil
+	p.queue = p.queue[:len(p.queue)-1]
+	return task, true
+}
+
+func (p *walkPool) done() {
+	p.mu.Lock()
+	if p.pending--; p.pending == 0 {
+		p.cond.Broadcast()
+	}
+	p.mu.Unlock()
+}
+
+// reserve takes a read-ahead slot for the task, or parks the task until a slot is
+// released and reports false.
+func (p *walkPool) reserve(task *dirTask) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.ahead < p.maxAhead {
+		p.ahead++
+		return true
+	}
+	// a parked task stays pending so that the workers wait for it
+	p.parked = append(p.parked, task)
+	p.pending++
+	return false
+}
+
+// release frees a read-ahead slot and queues the last parked task to take it.
+func (p *walkPool) release() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ahead--
+	if n := len(p.parked); n > 0 {
+		p.queue = append(p.queue, p.parked[n-1])
+		p.parked[n-1] = nil
+		p.parked = p.parked[:n-1]
+		p.cond.Signal()
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright (c) 2017. Oleg Sklyar & teris.io. All rights reserved.
+// See the LICENSE file in the project root for licensing information.
+
+package gitignore_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/teris-io/gitignore"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+type walkRecord struct {
+	path   string
+	result gitignore.MatchResult
+}
+
+func sequentialWalk(t testing.TB, root string) []walkRecord {
+	var records []walkRecord
+	err := gitignore.Walk(root, func(path string, d fs.DirEntry, result gitignore.MatchResult, err error) error {
+		records = append(records, walkRecord{path, result})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("no error expected, found %v", err)
+	}
+	return records
+}
+
+func parallelWalk(t testing.TB, root string, options gitignore.ParallelWalkOptions) []walkRecord {
+	var mu sync.Mutex
+	var records []walkRecord
+	err := gitignore.ParallelWalk(context.Background(), root, func(path string, d fs.DirEntry, result gitignore.MatchResult, err error) error {
+		mu.Lock()
+		records = append(records, walkRecord{path, result})
+		mu.Unlock()
+		return err
+	}, options)
+	if err != nil {
+		t.Fatalf("no error expected, found %v", err)
+	}
+	return records
+}
+
+func TestParallelWalk_ordered(t *testing.T) {
+	root := walkTree(t)
+	expected := sequentialWalk(t, root)
+	for _, workers := range []int{1, 2, 8} {
+		records := parallelWalk(t, root, gitignore.ParallelWalkOptions{Workers: workers, Ordered: true})
+		if !reflect.DeepEqual(records, expected) {
+			t.Errorf("%v workers: expected %v, found %v", workers, expected, records)
+		}
+	}
+}
+
+func TestParallelWalk_unordered(t *testing.T) {
+	root := walkTree(t)
+	expected := sequentialWalk(t, root)
+	records := parallelWalk(t, root, gitignore.ParallelWalkOptions{Workers: 4})
+	sort.Slice(records, func(i, j int) bool { return records[i].path < records[j].path })
+	sort.Slice(expected, func(i, j int) bool { return expected[i].path < expected[j].path })
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("expected %v, found %v", expected, records)
+	}
+}
+
+func TestParallelWalk_skipDir(t *testing.T) {
+	root := walkTree(t)
+	for _, ordered := range []bool{true, false} {
+		var mu sync.Mutex
+		var found []string
+		err := gitignore.ParallelWalk(context.Background(), root, func(path string, d fs.DirEntry, result gitignore.MatchResult, err error) error {
+			mu.Lock()
+			defer mu.Unlock()
+			found = append(found, path)
+			if d.IsDir() && d.Name() == "src" {
+				return filepath.SkipDir
+			}
+			return err
+		}, gitignore.ParallelWalkOptions{Ordered: ordered})
+		if err != nil {
+			t.Fatalf("no error expected, found %v", err)
+		}
+		for _, path := range found {
+			if path == filepath.Join(root, "src", "keep.log") {
+				t.Errorf("ordered=%v: expected src to be skipped", ordered)
+			}
+		}
+	}
+}
+
+func TestParallelWalk_cancel(t *testing.T) {
+	root := walkTree(t)
+	for _, ordered := range []bool{true, false} {
+		ctx, cancel := context.WithCancel(context.Background())
+		err := gitignore.ParallelWalk(ctx, root, func(path string, d fs.DirEntry, result gitignore.MatchResult, err error) error {
+			cancel()
+			return err
+		}, gitignore.ParallelWalkOptions{Ordered: ordered})
+		if err != context.Canceled {
+			t.Errorf("ordered=%v: expected %v, found %v", ordered, context.Canceled, err)
+		}
+	}
+}
+
+func TestParallelWalk_error(t *testing.T) {
+	root := walkTree(t)
+	expected := fmt.Errorf("stop")
+	for _, ordered := range []bool{true, false} {
+		err := gitignore.ParallelWalk(context.Background(), root, func(path string, d fs.DirEntry, result gitignore.MatchResult, err error) error {
+			if d.Name() == "main.go" {
+				return expected
+			}
+			return err
+		}, gitignore.ParallelWalkOptions{Ordered: ordered})
+		if err != expected {
+			t.Errorf("ordered=%v: expected %v, found %v", ordered, expected, err)
+		}
+	}
+}
+
+func TestParallelWalk_readAhead(t *testing.T) {
+	root := t.TempDir()
+	var dirs []string
+	for i := 0; i < 100; i++ {
+		name := fmt.Sprintf("d%03d", i)
+		dirs = append(dirs, filepath.Join(root, name))
+		writeFile(t, root, name+"/a", "")
+	}
+	options := gitignore.ParallelWalkOptions{Workers: 4, Ordered: true, ReadAhead: 4}
+	added := make(map[string]bool)
+	err := gitignore.ParallelWalk(context.Background(), root, func(path string, d fs.DirEntry, result gitignore.MatchResult, err error) error {
+		if path == dirs[0] {
+			// a slow call gives the workers the time to read the whole tree ahead
+			time.Sleep(50 * time.Millisecond)
+			for _, dir := range dirs {
+				writeFile(t, dir, "b", "")
+			}
+		} else if filepath.Base(path) == "b" {
+			added[filepath.Dir(path)] = true
+		}
+		return err
+	}, options)
+	if err != nil {
+		t.Fatalf("no error expected, found %v", err)
+	}
+	if missed := len(dirs) - len(added); missed > options.ReadAhead {
+		t.Errorf("expected at most %d directories read ahead, found %d", options.ReadAhead, missed)
+	}
+}
+
+// syntheticTree creates a tree of depth levels with the given fan-out of directories and
+// files per directory and a .gitignore file in every directory.
+func syntheticTree(b *testing.B, depth, fanout int) string {
+	root := b.TempDir()
+	var create func(dir string, level int)
+	create = func(dir string, level int) {
+		writeFile(b, dir, ".gitignore", fmt.Sprintf("*.tmp\n!keep%d.tmp\nbuild-%d/\n", level, level))
+		for i := 0; i < fanout; i++ {
+			writeFile(b, dir, fmt.Sprintf("file%d.go", i), "")
+			writeFile(b, dir, fmt.Sprintf("file%d.tmp", i), "")
+			if level < depth {
+				create(filepath.Join(dir, fmt.Sprintf("dir%d", i)), level+1)
+			}
+		}
+	}
+	create(root, 1)
+	return root
+}
+
+func BenchmarkWalk(b *testing.B) {
+	root := syntheticTree(b, 4, 6)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sequentialWalk(b, root)
+	}
+}
+
+func BenchmarkParallelWalk_unordered(b *testing.B) {
+	root := syntheticTree(b, 4, 6)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		parallelWalk(b, root, gitignore.ParallelWalkOptions{})
+	}
+}
+
+func BenchmarkParallelWalk_ordered(b *testing.B) {
+	root := syntheticTree(b, 4, 6)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		parallelWalk(b, root, gitignore.ParallelWalkOptions{Ordered: true})
+	}
+}
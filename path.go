@@ -0,0 +1,103 @@
+// Copyright (c) 2017. Oleg Sklyar & teris.io. All rights reserved.
+// See the LICENSE file in the project root for licensing information.
+
+package gitignore
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"unsafe"
+)
+
+// ErrPathOutsideRoot is returned for paths that do not lie within the root of the tree.
+var ErrPathOutsideRoot = errors.New("gitignore: path outside of the root")
+
+// SplitPath splits a path relative to the root of the tree into its components. Both
+// slashes and the separator of the OS are accepted, and "." and ".." components are
+// resolved. Absolute paths and paths leading out of the root fail with
+// ErrPathOutsideRoot. The root itself has no components.
+func SplitPath(name string) ([]string, error) {
+	name = filepath.ToSlash(name)
+	if path.IsAbs(name) || filepath.IsAbs(filepath.FromSlash(name)) {
+		return nil, fmt.Errorf("%w: %q is absolute", ErrPathOutsideRoot, name)
+	}
+	name = path.Clean(name)
+	if name == ".." || strings.HasPrefix(name, "../") {
+		return nil, fmt.Errorf("%w: %q", ErrPathOutsideRoot, name)
+	}
+	if name == "." {
+		return nil, nil
+	}
+	return strings.Split(name, "/"), nil
+}
+
+// matchPath matches a path given as a string, see SplitPath, with the matcher.
+func matchPath(m Matcher, name string, isDir bool) (bool, error) {
+	path, err := SplitPath(name)
+	if err != nil {
+		return false, err
+	}
+	return m.Match(path, isDir), nil
+}
+
+// matchFile matches a file of the file system tree rooted at root with the matcher. The
+// name is either relative to the root or an absolute path within it.
+func matchFile(m Matcher, root, name string) (bool, error) {
+	filename := name
+	if filepath.IsAbs(name) {
+		var err error
+		if name, err = filepath.Rel(root, name); err != nil {
+			return false, fmt.Errorf("%w: %v", ErrPathOutsideRoot, err)
+		}
+	} else {
+		filename = filepath.Join(root, name)
+	}
+	path, err := SplitPath(name)
+	if err != nil {
+		return false, err
+	}
+	fi, err := os.Lstat(filename)
+	if err != nil {
+		return false, err
+	}
+	return m.Match(path, fi.IsDir()), nil
+}
+
+// maxStackDepth is the number of path components split without allocating.
+const maxStackDepth = 32
+
+// splitString splits a slash separated path into the buffer skipping empty components.
+// The components refer to the path without copying, so with a buffer on the stack
+// deep enough no allocation takes place. The OS separator is accepted as well.
+func splitString(buf []string, path string) []string {
+	start := 0
+	for i := 0; i <= len(path); i++ {
+		if i < len(path) && path[i] != '/' && path[i] != os.PathSeparator {
+			continue
+		}
+		if i > start {
+			buf = append(buf, path[start:i])
+		}
+		start = i + 1
+	}
+	return buf
+}
+
+// heapPath copies a path split on the stack for patterns implemented outside of this
+// package, which may keep it, and returns nil when there are none.
+func heapPath(path []string, foreign bool) []string {
+	if !foreign {
+		return nil
+	}
+	return append([]string(nil), path...)
+}
+
+// bytesToString returns the bytes as a string without copying. The string must neither
+// outlive the match it is made for nor the bytes be modified meanwhile.
+func bytesToString(b []byte) string {
+	return unsafe.String(unsafe.SliceData(b), len(b))
+}
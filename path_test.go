@@ -0,0 +1,101 @@
+// Copyright (c) 2017. Oleg Sklyar & teris.io. All rights reserved.
+// See the LICENSE file in the project root for licensing information.
+
+package gitignore_test
+
+import (
+	"errors"
+	"fmt"
+	"github.com/teris-io/gitignore"
+	"path/filepath"
+	"testing"
+)
+
+func TestSplitPath(t *testing.T) {
+	cases := map[string][]string{
+		"a/b/c":                 {"a", "b", "c"},
+		filepath.Join("a", "b"): {"a", "b"},
+		"./a//b/":               {"a", "b"},
+		"a/../b/./c":            {"b", "c"},
+		".":                     nil,
+		"a/..":                  nil,
+		"a/b/../../c/..":        nil,
+	}
+	for name, expected := range cases {
+		path, err := gitignore.SplitPath(name)
+		if err != nil {
+			t.Errorf("%s: expected no error, found %v", name, err)
+		}
+		if fmt.Sprint(path) != fmt.Sprint(expected) {
+			t.Errorf("%s: expected %v, found %v", name, expected, path)
+		}
+	}
+}
+
+func TestSplitPath_outsideRoot(t *testing.T) {
+	for _, name := range []string{"..", "../a", "a/../../b", "/a/b"} {
+		if _, err := gitignore.SplitPath(name); !errors.Is(err, gitignore.ErrPathOutsideRoot) {
+			t.Errorf("%s: expected ErrPathOutsideRoot, found %v", name, err)
+		}
+	}
+}
+
+func TestMatcher_MatchPath(t *testing.T) {
+	m := gitignore.NewMatcher([]gitignore.Pattern{
+		gitignore.ParsePattern("build/", nil),
+		gitignore.ParsePattern("*.log", nil),
+	})
+	cases := []struct {
+		path     string
+		isDir    bool
+		expected bool
+	}{
+		{"build", true, true},
+		{"build", false, false},
+		{"a/../build/x.go", false, true},
+		{filepath.Join("src", "debug.log"), false, true},
+		{"src/main.go", false, false},
+	}
+	for _, tc := range cases {
+		if excluded, err := m.MatchPath(tc.path, tc.isDir); err != nil || excluded != tc.expected {
+			t.Errorf("%s: expected %v, found %v, %v", tc.path, tc.expected, excluded, err)
+		}
+	}
+	if _, err := m.MatchPath("../x.log", false); !errors.Is(err, gitignore.ErrPathOutsideRoot) {
+		t.Errorf("expected ErrPathOutsideRoot, found %v", err)
+	}
+}
+
+func TestPattern_MatchPath(t *testing.T) {
+	pattern := gitignore.ParsePattern("/a/*.go", nil)
+	if res, err := pattern.MatchPath("./a/main.go", false); err != nil || res != gitignore.Exclude {
+		t.Errorf("expected Exclude, found %v, %v", res, err)
+	}
+	if res, err := pattern.MatchPath("b/../../a/main.go", false); err == nil {
+		t.Errorf("expected an error, found %v", res)
+	}
+}
+
+func TestMatcher_MatchFile(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "build/out.bin", "")
+	writeFile(t, root, "src/build", "")
+	m := gitignore.NewMatcher([]gitignore.Pattern{gitignore.ParsePattern("build/", nil)})
+	cases := map[string]bool{
+		"build":                                 true,
+		"src/build":                             false,
+		filepath.Join(root, "build", "out.bin"): true,
+		filepath.Join(root, "src", "build"):     false,
+	}
+	for name, expected := range cases {
+		if excluded, err := m.MatchFile(root, name); err != nil || excluded != expected {
+			t.Errorf("%s: expected %v, found %v, %v", name, expected, excluded, err)
+		}
+	}
+	if _, err := m.MatchFile(root, "missing"); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+	if _, err := m.MatchFile(root, filepath.Dir(root)); !errors.Is(err, gitignore.ErrPathOutsideRoot) {
+		t.Errorf("expected ErrPathOutsideRoot, found %v", err)
+	}
+}
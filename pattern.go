@@ -4,8 +4,10 @@
 package gitignore
 
 import (
-	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 )
 
 // MatchResult defines outcomes of a match, no match, exclusion or inclusion.
@@ -20,10 +22,53 @@ const (
 	Include
 )
 
+func (r MatchResult) String() string {
+	switch r {
+	case NoMatch:
+		return "NoMatch"
+	case Exclude:
+		return "Exclude"
+	case Include:
+		return "Include"
+	}
+	return "MatchResult(" + strconv.Itoa(int(r)) + ")"
+}
+
 // Pattern defines a single gitignore pattern.
 type Pattern interface {
 	// Match matches the given path to the pattern.
 	Match(path []string, isDir bool) MatchResult
+	// Origin tells where the pattern comes from.
+	Origin() Origin
+	// Regexp translates the pattern into a regular expression over slash separated
+	// paths with a trailing slash for directories that matches exactly when Match does.
+	Regexp() *regexp.Regexp
+	// MatchPath matches a path given as a string relative to the root of the tree, see
+	// SplitPath, and fails for paths outside of the root.
+	MatchPath(path string, isDir bool) (MatchResult, error)
+}
+
+// Origin describes where a pattern comes from.
+type Origin struct {
+	// Source is the file the pattern was read from, empty if not read from a file
+	Source string
+	// Line is the 1-based line number of the pattern in the source, 0 if unknown
+	Line int
+	// Raw is the line of the source defining the pattern as is
+	Raw string
+	// Layer is the exclude source the pattern belongs to
+	Layer Layer
+}
+
+// ParseOptions defines optional settings of pattern parsing.
+type ParseOptions struct {
+	// CaseInsensitive makes the pattern match paths irrespective of case, as git does
+	// with core.ignoreCase set. Domain components, literal characters and bracket
+	// expressions are compared under Unicode simple case folding.
+	CaseInsensitive bool
+	// Origin records where the pattern comes from. The pattern string is taken for the
+	// raw line when none is given.
+	Origin Origin
 }
 
 type ptrn struct {
@@ -32,48 +77,149 @@ type ptrn struct {
 	inclusion bool
 	dirOnly   bool
 	isGlob    bool
+	fold      bool
+	// text is the pattern as given to the parser
+	text   string
+	origin Origin
+	// regexp is compiled from the pattern on first use
+	regexpOnce sync.Once
+	regexp     *regexp.Regexp
 }
 
-// ParsePattern parses a gitignore pattern string into the Pattern structure.
+// matchFlags modify how a path is matched against a pattern.
+type matchFlags uint8
+
+const (
+	// matchExact matches the path itself, but not its parent directories
+	matchExact matchFlags = 1 << iota
+	// matchFold matches irrespective of case
+	matchFold
+)
+
+// ParsePattern parses a gitignore pattern string into the Pattern structure. Backslash
+// escapes follow git: a leading "\!" or "\#" stands for a literal character rather than
+// a negation or a comment, "\ " keeps a trailing space from being trimmed and any other
+// escaped character, including a backslash, matches literally.
 func ParsePattern(pattern string, domain []string) Pattern {
-	p := ptrn{domain: domain}
+	return ParsePatternWithOptions(pattern, domain, ParseOptions{})
+}
+
+// ParsePatternWithOptions parses a gitignore pattern string into the Pattern structure
+// with the given options.
+func ParsePatternWithOptions(pattern string, domain []string, options ParseOptions) Pattern {
+	p := ptrn{domain: domain, fold: options.CaseInsensitive, text: pattern, origin: options.Origin}
+	if p.origin.Raw == "" {
+		p.origin.Raw = pattern
+	}
 
 	if strings.HasPrefix(pattern, "!") {
 		p.inclusion = true
 		pattern = pattern[1:]
 	}
 
-	if !strings.HasSuffix(pattern, "\\ ") {
-		pattern = strings.TrimRight(pattern, " ")
-	}
+	pattern = trimTrailingSpaces(pattern)
 
 	if strings.HasSuffix(pattern, "/") {
 		p.dirOnly = true
 		pattern = pattern[:len(pattern)-1]
 	}
 
-	if strings.Contains(pattern, "/") {
-		p.isGlob = true
+	p.pattern, p.isGlob = splitPattern(pattern)
+	return &p
+}
+
+// isPatternLine reports whether a line read from a gitignore file defines a pattern,
+// that is whether it is neither blank nor a comment. An escaped hash, "\#", starts a
+// pattern rather than a comment.
+func isPatternLine(line string) bool {
+	return !strings.HasPrefix(line, "#") && trimTrailingSpaces(line) != ""
+}
+
+// trimTrailingSpaces removes trailing spaces unless they are escaped with a backslash.
+// An escaped backslash does not protect the space that follows it.
+func trimTrailingSpaces(pattern string) string {
+	end := 0
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] == '\\' && i+1 < len(pattern) {
+			i++
+			end = i + 1
+		} else if pattern[i] != ' ' {
+			end = i + 1
+		}
 	}
+	return pattern[:end]
+}
 
-	p.pattern = strings.Split(pattern, "/")
-	return &p
+// splitPattern splits a pattern into path segments at every separator, escaped or not,
+// and reports whether any separator was found. All other escape sequences are kept
+// in the segments and resolved by the name matching. As in git, a bracket expression is
+// kept whole: a slash within it does not split the pattern, but still anchors it.
+func splitPattern(pattern string) (segments []string, hasSeparator bool) {
+	var segment []byte
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		if c == '[' {
+			if _, n, valid := matchClass(pattern[i:], 0, false); valid {
+				segment = append(segment, pattern[i:i+n]...)
+				hasSeparator = hasSeparator || strings.IndexByte(pattern[i:i+n], '/') >= 0
+				i += n - 1
+				continue
+			}
+		}
+		if c == '\\' && i+1 < len(pattern) {
+			i++
+			if pattern[i] != '/' {
+				segment = append(segment, c, pattern[i])
+				continue
+			}
+			c = '/'
+		}
+		if c == '/' {
+			segments = append(segments, string(segment))
+			segment = segment[:0]
+			hasSeparator = true
+			continue
+		}
+		segment = append(segment, c)
+	}
+	return append(segments, string(segment)), hasSeparator
 }
 
 func (p *ptrn) Match(path []string, isDir bool) MatchResult {
+	return p.match(path, isDir, 0)
+}
+
+func (p *ptrn) MatchPath(name string, isDir bool) (MatchResult, error) {
+	path, err := SplitPath(name)
+	if err != nil {
+		return NoMatch, err
+	}
+	return p.Match(path, isDir), nil
+}
+
+func (p *ptrn) Origin() Origin {
+	return p.origin
+}
+
+// match matches the path against the pattern. Unless matchExact is set, a match of any
+// of the parent directories of the path counts as a match of the path itself.
+func (p *ptrn) match(path []string, isDir bool, flags matchFlags) MatchResult {
 	if len(path) <= len(p.domain) {
 		return NoMatch
 	}
+	if p.fold {
+		flags |= matchFold
+	}
 	for i, e := range p.domain {
-		if path[i] != e {
+		if path[i] != e && (flags&matchFold == 0 || !strings.EqualFold(path[i], e)) {
 			return NoMatch
 		}
 	}
 
 	path = path[len(p.domain):]
-	if p.isGlob && !p.globMatch(path, isDir) {
+	if p.isGlob && !p.globMatch(path, isDir, flags) {
 		return NoMatch
-	} else if !p.isGlob && !p.simpleNameMatch(path, isDir) {
+	} else if !p.isGlob && !p.simpleNameMatch(path, isDir, flags) {
 		return NoMatch
 	}
 
@@ -84,11 +230,9 @@ func (p *ptrn) Match(path []string, isDir bool) MatchResult {
 	}
 }
 
-func (p *ptrn) simpleNameMatch(path []string, isDir bool) bool {
+func (p *ptrn) simpleNameMatch(path []string, isDir bool, flags matchFlags) bool {
 	for i, name := range path {
-		if match, err := filepath.Match(p.pattern[0], name); err != nil {
-			return false
-		} else if !match {
+		if flags&matchExact != 0 && i < len(path)-1 || !matchName(p.pattern[0], name, flags&matchFold != 0) {
 			continue
 		}
 		if p.dirOnly && !isDir && i == len(path)-1 {
@@ -99,49 +243,90 @@ func (p *ptrn) simpleNameMatch(path []string, isDir bool) bool {
 	return false
 }
 
-func (p *ptrn) globMatch(path []string, isDir bool) bool {
-	matched := false
-	canTraverse := false
-	for i, pattern := range p.pattern {
-		if pattern == "" {
-			canTraverse = false
-			continue
+// globMatch matches the path, or unless exact any of its parent directories, against the
+// pattern segments. A "**" segment matches zero or more path components, or one or more
+// when it ends the pattern, and every possible split point is tried, so several "**"
+// runs in one pattern backtrack as in git.
+func (p *ptrn) globMatch(path []string, isDir bool, flags matchFlags) bool {
+	return p.globMatchFrom(0, path, 0, isDir, flags)
+}
+
+func (p *ptrn) globMatchFrom(pi int, path []string, i int, isDir bool, flags matchFlags) bool {
+	for pi < len(p.pattern) && p.pattern[pi] == "" {
+		pi++
+	}
+	if pi == len(p.pattern) {
+		if i == 0 {
+			return false
 		}
-		if pattern == "**" {
-			if i == len(p.pattern)-1 {
-				break
-			}
-			canTraverse = true
-			continue
+		if i < len(path) {
+			// the pattern matched a parent directory of the path
+			return flags&matchExact == 0
 		}
-		if strings.Contains(pattern, "**") {
-			return false
+		return !p.dirOnly || isDir
+	}
+	pattern := p.pattern[pi]
+	if isDoubleStar(pattern) {
+		for pi+1 < len(p.pattern) && (isDoubleStar(p.pattern[pi+1]) || p.pattern[pi+1] == "") {
+			pi++
 		}
-		if len(path) == 0 {
-			return false
+		j := i
+		if pi+1 == len(p.pattern) {
+			// a trailing "**" matches what is inside the directory, not the directory
+			j++
 		}
-		if canTraverse {
-			canTraverse = false
-			for len(path) > 0 {
-				e := path[0]
-				path = path[1:]
-				if match, err := filepath.Match(pattern, e); err != nil {
-					return false
-				} else if match {
-					matched = true
-					break
-				}
-			}
-		} else {
-			if match, err := filepath.Match(pattern, path[0]); err != nil || !match {
-				return false
+		for ; j <= len(path); j++ {
+			if p.globMatchFrom(pi+1, path, j, isDir, flags) {
+				return true
 			}
-			matched = true
-			path = path[1:]
 		}
+		return false
 	}
-	if matched && p.dirOnly && !isDir && len(path) == 0 {
-		matched = false
+	if i == len(path) {
+		return false
 	}
-	return matched
+	// asterisks within a segment match like a single "*"
+	if !matchName(pattern, path[i], flags&matchFold != 0) {
+		return false
+	}
+	return p.globMatchFrom(pi+1, path, i+1, isDir, flags)
+}
+
+// isDoubleStar reports whether a pattern segment is a "**" matching any number of path
+// components. As in git, a longer run of asterisks counts as "**".
+func isDoubleStar(segment string) bool {
+	if len(segment) < 2 {
+		return false
+	}
+	for i := 0; i < len(segment); i++ {
+		if segment[i] != '*' {
+			return false
+		}
+	}
+	return true
+}
+
+// matchPattern matches the path against the pattern with the given flags. Patterns
+// implemented outside of this package are matched as they are on the same path taken
+// from heap, the path given to Match or a copy of the path split on the stack by
+// MatchString, so that the latter never escapes through them.
+func matchPattern(pattern Pattern, path, heap []string, isDir bool, flags matchFlags) MatchResult {
+	if p, ok := pattern.(*ptrn); ok {
+		return p.match(path, isDir, flags)
+	}
+	if len(heap) < len(path) {
+		// the pattern was not known when the copy was due, see TreeMatcher
+		heap = append([]string(nil), path...)
+	}
+	return pattern.Match(heap[:len(path)], isDir)
+}
+
+// hasForeign reports whether any of the patterns is implemented outside of this package.
+func hasForeign(patterns []Pattern) bool {
+	for _, pattern := range patterns {
+		if _, ok := pattern.(*ptrn); !ok {
+			return true
+		}
+	}
+	return false
 }
@@ -246,10 +246,10 @@ func TestPatternGlobMatch_tailingAsterisks(t *testing.T) {
 	}
 }
 
-func TestPatternGlobMatch_tailingAsterisks_exactMatch(t *testing.T) {
+func TestPatternGlobMatch_tailingAsterisks_exactMatch_mismatch(t *testing.T) {
 	pattern := gitignore.ParsePattern("/*lue/vol?ano/**", nil)
-	if res := pattern.Match([]string{"value", "volcano"}, false); res != gitignore.Exclude {
-		t.Errorf("expected Exclude, found %v", res)
+	if res := pattern.Match([]string{"value", "volcano"}, true); res != gitignore.NoMatch {
+		t.Errorf("expected NoMatch, found %v", res)
 	}
 }
 
@@ -295,9 +295,16 @@ func TestPatternGlobMatch_middleAsterisks_isDir(t *testing.T) {
 	}
 }
 
-func TestPatternGlobMatch_wrongDoubleAsterisk_mismatch(t *testing.T) {
+func TestPatternGlobMatch_partialDoubleAsterisk(t *testing.T) {
 	pattern := gitignore.ParsePattern("/*lue/**foo/vol?ano", nil)
-	if res := pattern.Match([]string{"value", "foo", "volcano", "tail"}, false); res != gitignore.NoMatch {
+	if res := pattern.Match([]string{"value", "foo", "volcano", "tail"}, false); res != gitignore.Exclude {
+		t.Errorf("expected Exclude, found %v", res)
+	}
+}
+
+func TestPatternGlobMatch_partialDoubleAsterisk_mismatch(t *testing.T) {
+	pattern := gitignore.ParsePattern("/*lue/**foo/vol?ano", nil)
+	if res := pattern.Match([]string{"value", "x", "foo", "volcano"}, false); res != gitignore.NoMatch {
 		t.Errorf("expected NoMatch, found %v", res)
 	}
 }
@@ -322,3 +329,216 @@ func TestPatternGlobMatch_wrongPattern_onTraversal_mismatch(t *testing.T) {
 		t.Errorf("expected NoMatch, found %v", res)
 	}
 }
+
+// gitConformance lists glob patterns with multiple or backtracking "**" runs together
+// with the outcome reported by `git check-ignore --no-index` for the given path.
+var gitConformance = []struct {
+	pattern string
+	path    []string
+	isDir   bool
+	ignored bool
+}{
+	{"a/**/b/c", []string{"a", "b", "x", "b", "c"}, false, true},
+	{"a/**/b/c", []string{"a", "b", "c"}, false, true},
+	{"a/**/b/c", []string{"a", "x", "y", "b", "c"}, false, true},
+	{"a/**/b/c", []string{"a", "b", "x", "c"}, false, false},
+	{"**/b/**/d", []string{"x", "b", "y", "b", "z", "d"}, false, true},
+	{"**/b/**/d", []string{"b", "d"}, false, true},
+	{"**/b/**/d", []string{"x", "y", "d"}, false, false},
+	{"a/**/b/**/c", []string{"a", "b", "b", "c"}, false, true},
+	{"a/**/b/**/c", []string{"a", "x", "b", "y", "c"}, false, true},
+	{"a/**/b/**/c", []string{"a", "c"}, false, false},
+	{"a/**/b/**/c", []string{"a", "b", "x", "b", "c"}, false, true},
+	{"**/x/**/x/y", []string{"x", "x", "y"}, false, true},
+	{"**/x/**/x/y", []string{"a", "x", "b", "x", "c", "x", "y"}, false, true},
+	{"**/x/**/x/y", []string{"x", "y"}, false, false},
+	{"a/**/b/", []string{"a", "b"}, true, true},
+	{"a/**/b/", []string{"a", "x", "b"}, false, false},
+	{"a/**/b/", []string{"a", "b", "x", "b"}, true, true},
+	{"**/foo", []string{"foo"}, false, true},
+	{"**/foo", []string{"a", "b", "foo"}, false, true},
+	{"foo/**", []string{"foo", "a", "b"}, false, true},
+	{"foo/**", []string{"foo"}, true, false},
+	{"foo/**", []string{"foo"}, false, false},
+	{"foo/**/", []string{"foo"}, true, false},
+	{"foo/**/", []string{"foo", "x"}, true, true},
+	{"**/b/**", []string{"x", "a", "b"}, false, false},
+	{"**/b/**", []string{"x", "b", "c"}, false, true},
+	{"a/foo**bar", []string{"a", "fooxbar"}, false, true},
+	{"a/foo**bar", []string{"a", "foo", "x", "bar"}, false, false},
+	{"a/**bar", []string{"a", "ybar"}, false, true},
+	{"a/**bar", []string{"a", "x", "ybar"}, false, false},
+	{"a/***/x", []string{"a", "b", "x"}, false, true},
+	{"a/***/x", []string{"a", "x"}, false, true},
+	{"***/x", []string{"a", "b", "x"}, false, true},
+	{"a/***", []string{"a"}, true, false},
+	{"[a/b]", []string{"a"}, false, true},
+	{"[a/b]", []string{"b"}, false, true},
+	{"[a/b]", []string{"x", "a"}, false, false},
+	{"x[a/b]y", []string{"xay"}, false, true},
+	{"x[a/b]y", []string{"x", "y"}, false, false},
+	{"[a/b]/c", []string{"a", "c"}, false, true},
+	{"a/**/**/b", []string{"a", "b"}, false, true},
+	{"a/**/**/b", []string{"a", "x", "y", "b"}, false, true},
+}
+
+func TestPatternGlobMatch_gitConformance(t *testing.T) {
+	for _, tc := range gitConformance {
+		pattern := gitignore.ParsePattern(tc.pattern, nil)
+		expected := gitignore.NoMatch
+		if tc.ignored {
+			expected = gitignore.Exclude
+		}
+		if res := pattern.Match(tc.path, tc.isDir); res != expected {
+			t.Errorf("%q on %v (dir=%v): expected %v, found %v", tc.pattern, tc.path, tc.isDir, expected, res)
+		}
+	}
+}
+
+// gitMatcherConformance lists sets of patterns together with the outcome reported by
+// `git status --ignored` for the given path.
+var gitMatcherConformance = []struct {
+	patterns []string
+	path     []string
+	isDir    bool
+	ignored  bool
+}{
+	{[]string{"foo/**", "!foo/keep"}, []string{"foo", "keep"}, false, false},
+	{[]string{"foo/**", "!foo/keep"}, []string{"foo", "other"}, false, true},
+	{[]string{"foo/**", "!foo/keep"}, []string{"foo"}, true, false},
+	{[]string{"a/***", "!a/b"}, []string{"a", "b"}, false, false},
+}
+
+func TestMatcher_Match_gitConformance(t *testing.T) {
+	for _, tc := range gitMatcherConformance {
+		var patterns []gitignore.Pattern
+		for _, p := range tc.patterns {
+			patterns = append(patterns, gitignore.ParsePattern(p, nil))
+		}
+		if res := gitignore.NewMatcher(patterns).Match(tc.path, tc.isDir); res != tc.ignored {
+			t.Errorf("%q on %v (dir=%v): expected %v, found %v", tc.patterns, tc.path, tc.isDir, tc.ignored, res)
+		}
+	}
+}
+
+func TestParsePattern_escapes(t *testing.T) {
+	cases := []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{`\!important!.txt`, "!important!.txt", true},
+		{`\#foo`, "#foo", true},
+		{`\*`, "*", true},
+		{`\*`, "abc", false},
+		{`foo\ `, "foo ", true},
+		{`foo\ `, "foo", false},
+		{`foo\  `, "foo ", true},
+		{`foo\ \ `, "foo  ", true},
+		{`foo\\`, `foo\`, true},
+		{`foo\\ `, `foo\`, true},
+		{`a\b`, "ab", true},
+	}
+	for _, tc := range cases {
+		expected := gitignore.NoMatch
+		if tc.match {
+			expected = gitignore.Exclude
+		}
+		pattern := gitignore.ParsePattern(tc.pattern, nil)
+		if res := pattern.Match([]string{"head", tc.name}, false); res != expected {
+			t.Errorf("%q on %q: expected %v, found %v", tc.pattern, tc.name, expected, res)
+		}
+	}
+}
+
+func TestParsePattern_escapedNegation_notInclusion(t *testing.T) {
+	pattern := gitignore.ParsePattern(`\!foo`, nil)
+	if res := pattern.Match([]string{"!foo"}, false); res != gitignore.Exclude {
+		t.Errorf("expected Exclude, found %v", res)
+	}
+}
+
+func TestParsePattern_escapedSeparator(t *testing.T) {
+	pattern := gitignore.ParsePattern(`a\/b`, nil)
+	if res := pattern.Match([]string{"a", "b"}, false); res != gitignore.Exclude {
+		t.Errorf("expected Exclude, found %v", res)
+	}
+	if res := pattern.Match([]string{"x", "a", "b"}, false); res != gitignore.NoMatch {
+		t.Errorf("expected NoMatch, found %v", res)
+	}
+}
+
+func TestParsePatternWithOptions_caseInsensitive(t *testing.T) {
+	options := gitignore.ParseOptions{CaseInsensitive: true}
+	cases := []struct {
+		pattern string
+		domain  []string
+		path    []string
+		match   bool
+	}{
+		{"*.LOG", nil, []string{"Debug.log"}, true},
+		{"Build/", nil, []string{"src", "BUILD"}, true},
+		{"/docs/*.md", nil, []string{"DOCS", "README.MD"}, true},
+		{"[A-C]x", nil, []string{"bX"}, true},
+		{"[!a-c]x", nil, []string{"Bx"}, false},
+		{"[[:upper:]]x", nil, []string{"ax"}, true},
+		{"straße", nil, []string{"STRASSE"}, false},
+		{"ÄRGER", nil, []string{"ärger"}, true},
+		{"[ä-ö]", nil, []string{"Ö"}, true},
+		{"kelvin", nil, []string{"\u212aelvin"}, true},
+		{"value", []string{"Head", "Middle"}, []string{"head", "MIDDLE", "VALUE"}, true},
+	}
+	for _, tc := range cases {
+		expected := gitignore.NoMatch
+		if tc.match {
+			expected = gitignore.Exclude
+		}
+		pattern := gitignore.ParsePatternWithOptions(tc.pattern, tc.domain, options)
+		if res := pattern.Match(tc.path, true); res != expected {
+			t.Errorf("%q on %v: expected %v, found %v", tc.pattern, tc.path, expected, res)
+		}
+		if res := gitignore.ParsePattern(tc.pattern, tc.domain).Match(tc.path, true); tc.match && res != gitignore.NoMatch {
+			t.Errorf("%q on %v: expected case-sensitive NoMatch, found %v", tc.pattern, tc.path, res)
+		}
+	}
+}
+
+func TestParsePattern_origin(t *testing.T) {
+	pattern := gitignore.ParsePattern("*.o ", nil)
+	if origin := pattern.Origin(); origin != (gitignore.Origin{Raw: "*.o "}) {
+		t.Errorf("unexpected origin %+v", origin)
+	}
+	origin := gitignore.Origin{Source: "build/.gitignore", Line: 7, Raw: "*.o # objects", Layer: gitignore.GitignoreLayer}
+	pattern = gitignore.ParsePatternWithOptions("*.o", []string{"build"}, gitignore.ParseOptions{Origin: origin})
+	if pattern.Origin() != origin {
+		t.Errorf("expected %+v, found %+v", origin, pattern.Origin())
+	}
+}
+
+func TestMatchResult_String(t *testing.T) {
+	cases := map[gitignore.MatchResult]string{
+		gitignore.NoMatch:         "NoMatch",
+		gitignore.Exclude:         "Exclude",
+		gitignore.Include:         "Include",
+		gitignore.MatchResult(42): "MatchResult(42)",
+	}
+	for res, expected := range cases {
+		if s := res.String(); s != expected {
+			t.Errorf("expected %v, found %v", expected, s)
+		}
+	}
+}
+
+func TestPattern_EscapedDoubleStar(t *testing.T) {
+	pattern := gitignore.ParsePattern("a/\\**", nil)
+	if res := pattern.Match([]string{"a", "*x"}, false); res != gitignore.Exclude {
+		t.Errorf("expected Exclude, found %v", res)
+	}
+	if res := pattern.Match([]string{"a", "x"}, false); res != gitignore.NoMatch {
+		t.Errorf("expected NoMatch, found %v", res)
+	}
+	pattern = gitignore.ParsePattern("a/[**]", nil)
+	if res := pattern.Match([]string{"a", "*"}, false); res != gitignore.Exclude {
+		t.Errorf("expected Exclude, found %v", res)
+	}
+}
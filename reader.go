@@ -0,0 +1,73 @@
+// Copyright (c) 2017. Oleg Sklyar & teris.io. All rights reserved.
+// See the LICENSE file in the project root for licensing information.
+
+package gitignore
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+)
+
+// MaxDocumentSize is the size limit of a gitignore document, the same as git's.
+const MaxDocumentSize = 100 << 20
+
+// ErrDocumentTooLarge is returned for documents exceeding MaxDocumentSize. Git ignores
+// such files altogether.
+var ErrDocumentTooLarge = errors.New("gitignore: document exceeds the size limit")
+
+// ParseReader parses a whole gitignore document read from the reader into patterns in
+// the order of definition skipping blank lines and comments, and reports the problems
+// found in them as ParsePatternWithDiagnostics does. Lines may end with "\n" or "\r\n"
+// and a leading UTF-8 byte order mark is skipped. The patterns take the source and the
+// layer from the origin of the options and record their lines. No patterns are returned
+// on error.
+func ParseReader(r io.Reader, domain []string, options ParseOptions) ([]Pattern, []Diagnostic, error) {
+	var patterns []Pattern
+	var diagnostics []Diagnostic
+	lines := newLineReader(r)
+	for {
+		line, err := lines.next()
+		if err == io.EOF {
+			return patterns, diagnostics, nil
+		} else if err != nil {
+			return nil, nil, err
+		}
+		if !isPatternLine(line) {
+			continue
+		}
+		options.Origin.Line, options.Origin.Raw = lines.line, line
+		p, ds := ParsePatternWithDiagnostics(line, domain, options)
+		patterns = append(patterns, p)
+		diagnostics = append(diagnostics, ds...)
+	}
+}
+
+// lineReader splits a gitignore document into lines without their line endings.
+type lineReader struct {
+	r    *bufio.Reader
+	size int
+	// line is the 1-based number of the line last read
+	line int
+}
+
+func newLineReader(r io.Reader) *lineReader {
+	return &lineReader{r: bufio.NewReader(io.LimitReader(r, MaxDocumentSize+1))}
+}
+
+// next returns the next line, or io.EOF after the last one.
+func (lr *lineReader) next() (string, error) {
+	line, err := lr.r.ReadString('\n')
+	if lr.size += len(line); lr.size > MaxDocumentSize {
+		return "", ErrDocumentTooLarge
+	}
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	if lr.line++; lr.line == 1 {
+		line = strings.TrimPrefix(line, "\xef\xbb\xbf")
+	}
+	line = strings.TrimSuffix(line, "\n")
+	return strings.TrimSuffix(line, "\r"), nil
+}
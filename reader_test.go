@@ -0,0 +1,77 @@
+// Copyright (c) 2017. Oleg Sklyar & teris.io. All rights reserved.
+// See the LICENSE file in the project root for licensing information.
+
+package gitignore_test
+
+import (
+	"errors"
+	"github.com/teris-io/gitignore"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestParseReader(t *testing.T) {
+	doc := "\xef\xbb\xbf*.log\r\n# comment\r\n\r\n!keep.log\r\nbuild/\r\nv[ou"
+	options := gitignore.ParseOptions{Origin: gitignore.Origin{Source: "stdin", Layer: gitignore.CommandLineLayer}}
+	patterns, diagnostics, err := gitignore.ParseReader(strings.NewReader(doc), []string{"a"}, options)
+	if err != nil {
+		t.Fatalf("expected no error, found %v", err)
+	}
+	if len(patterns) != 4 {
+		t.Fatalf("expected 4 patterns, found %d", len(patterns))
+	}
+	expected := []struct {
+		line int
+		raw  string
+	}{{1, "*.log"}, {4, "!keep.log"}, {5, "build/"}, {6, "v[ou"}}
+	for i, e := range expected {
+		origin := patterns[i].Origin()
+		if origin.Line != e.line || origin.Raw != e.raw || origin.Source != "stdin" || origin.Layer != gitignore.CommandLineLayer {
+			t.Errorf("expected line %d %q from stdin, found %+v", e.line, e.raw, origin)
+		}
+	}
+	if res := patterns[0].Match([]string{"a", "x.log"}, false); res != gitignore.Exclude {
+		t.Errorf("expected Exclude, found %v", res)
+	}
+	if res := patterns[2].Match([]string{"a", "build"}, true); res != gitignore.Exclude {
+		t.Errorf("expected Exclude, found %v", res)
+	}
+	if len(diagnostics) != 1 || diagnostics[0].Line != 6 || diagnostics[0].Source != "stdin" {
+		t.Errorf("expected a diagnostic on line 6 of stdin, found %v", diagnostics)
+	}
+}
+
+func TestParseReader_TooLarge(t *testing.T) {
+	r := io.LimitReader(repeatReader('x'), gitignore.MaxDocumentSize+1)
+	patterns, _, err := gitignore.ParseReader(r, nil, gitignore.ParseOptions{})
+	if !errors.Is(err, gitignore.ErrDocumentTooLarge) {
+		t.Errorf("expected ErrDocumentTooLarge, found %v", err)
+	}
+	if patterns != nil {
+		t.Errorf("expected no patterns, found %d", len(patterns))
+	}
+}
+
+func TestParseReader_ReadError(t *testing.T) {
+	failure := errors.New("failure")
+	r := io.MultiReader(strings.NewReader("*.log\n"), errReader{failure})
+	if _, _, err := gitignore.ParseReader(r, nil, gitignore.ParseOptions{}); err != failure {
+		t.Errorf("expected %v, found %v", failure, err)
+	}
+}
+
+type repeatReader byte
+
+func (r repeatReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(r)
+	}
+	return len(p), nil
+}
+
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,291 @@
+// Copyright (c) 2017. Oleg Sklyar & teris.io. All rights reserved.
+// See the LICENSE file in the project root for licensing information.
+
+package gitignore
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// neverMatch is a regular expression that does not match anything.
+const neverMatch = `[^\x00-\x{10FFFF}]`
+
+// Regexp translates the pattern into a regular expression matching slash separated
+// paths, relative to the root of the tree and with a trailing slash for directories,
+// exactly when Match matches the path: "a/b" for a file and "a/b/" for a directory. The
+// expression is compiled on first use and shared by all calls.
+func (p *ptrn) Regexp() *regexp.Regexp {
+	p.regexpOnce.Do(func() {
+		p.regexp = regexp.MustCompile(p.regexpSource(false))
+	})
+	return p.regexp
+}
+
+// regexpSource returns the regular expression of the pattern. With exact set it only
+// matches the path itself as with matchExact, otherwise it also matches the paths under
+// a matching directory.
+func (p *ptrn) regexpSource(exact bool) string {
+	var b strings.Builder
+	if p.fold {
+		b.WriteString("(?i)")
+	}
+	b.WriteByte('^')
+	for _, name := range p.domain {
+		b.WriteString(regexp.QuoteMeta(name))
+		b.WriteByte('/')
+	}
+
+	if !p.isGlob {
+		name, ok := nameRegexp(p.pattern[0])
+		if !ok {
+			return neverMatch
+		}
+		b.WriteString("(?:[^/]+/)*")
+		b.WriteString(name)
+	} else {
+		// the segments after the last name are all "**" or empty
+		last := -1
+		for i, segment := range p.pattern {
+			if segment != "" && !isDoubleStar(segment) {
+				last = i
+			}
+		}
+		start, star := true, false
+		for i, segment := range p.pattern {
+			if segment == "" {
+				continue
+			}
+			if isDoubleStar(segment) {
+				if star {
+					continue
+				}
+				star = true
+				switch {
+				case start && i > last:
+					// a pattern made of "**" alone still needs to match a name
+					b.WriteString("(?:[^/]+/)*[^/]+")
+				case start:
+					b.WriteString("(?:[^/]+/)*")
+				case i > last:
+					// a trailing "**" matches what is inside the directory
+					b.WriteString("(?:/[^/]+)+")
+				default:
+					b.WriteString("(?:/[^/]+)*")
+				}
+				continue
+			}
+			star = false
+			name, ok := nameRegexp(segment)
+			if !ok {
+				return neverMatch
+			}
+			if !start {
+				b.WriteByte('/')
+			}
+			b.WriteString(name)
+			start = false
+		}
+	}
+
+	switch {
+	case p.dirOnly && exact:
+		b.WriteString("/$")
+	case p.dirOnly:
+		b.WriteByte('/')
+	case exact:
+		b.WriteString("/?$")
+	default:
+		b.WriteString("(?:/|$)")
+	}
+	return b.String()
+}
+
+// nameRegexp translates a pattern segment into a regular expression matching a single
+// non-empty path component as matchName does. It fails for a malformed segment.
+func nameRegexp(segment string) (string, bool) {
+	var b strings.Builder
+	stars := true
+	for i := 0; i < len(segment); {
+		switch segment[i] {
+		case '*':
+			for i < len(segment) && segment[i] == '*' {
+				i++
+			}
+			b.WriteString("[^/]*")
+			continue
+		case '?':
+			b.WriteString("[^/]")
+			i++
+		case '[':
+			class, n, ok := classRegexp(segment[i:])
+			if !ok {
+				return "", false
+			}
+			b.WriteString(class)
+			i += n
+		case '\\':
+			if i+1 == len(segment) {
+				return "", false
+			}
+			i++
+			fallthrough
+		default:
+			r, w := utf8.DecodeRuneInString(segment[i:])
+			if r == utf8.RuneError {
+				// invalid UTF-8 is matched as the replacement character
+				b.WriteString(`\x{fffd}`)
+			} else {
+				b.WriteString(regexp.QuoteMeta(segment[i : i+w]))
+			}
+			i += w
+		}
+		stars = false
+	}
+	if segment == "" {
+		return "", false
+	}
+	if stars {
+		// the component is not empty, which "*" alone would match
+		return "[^/]+", true
+	}
+	return b.String(), true
+}
+
+// classRegexp translates the bracket expression at the start of the pattern into a
+// character class as matchClass interprets it and returns its width.
+func classRegexp(pattern string) (class string, width int, ok bool) {
+	var b strings.Builder
+	i := 1
+	if i < len(pattern) && (pattern[i] == '!' || pattern[i] == '^') {
+		b.WriteString("[^/")
+		i++
+	} else {
+		b.WriteByte('[')
+	}
+	empty := true
+	for first := true; ; first = false {
+		if i >= len(pattern) {
+			return "", 0, false
+		}
+		c := pattern[i]
+		if c == ']' && !first {
+			i++
+			break
+		}
+		if c == '[' && i+1 < len(pattern) && pattern[i+1] == ':' {
+			if end := strings.IndexByte(pattern[i+2:], ']'); end > 0 && pattern[i+2+end-1] == ':' {
+				name := pattern[i+2 : i+2+end-1]
+				if _, known := posixClass(name, 0); !known {
+					return "", 0, false
+				}
+				b.WriteString("[:" + name + ":]")
+				empty = false
+				i += 2 + end + 1
+				continue
+			}
+		}
+		if c == '\\' {
+			if i++; i >= len(pattern) {
+				return "", 0, false
+			}
+		}
+		lo, w := utf8.DecodeRuneInString(pattern[i:])
+		i += w
+		hi := lo
+		if i+1 < len(pattern) && pattern[i] == '-' && pattern[i+1] != ']' {
+			i++
+			if pattern[i] == '\\' {
+				if i++; i >= len(pattern) {
+					return "", 0, false
+				}
+			}
+			hi, w = utf8.DecodeRuneInString(pattern[i:])
+			i += w
+		}
+		// an inverted range matches nothing and a class never matches the separator
+		ranges := [][2]rune{{lo, hi}}
+		if lo <= '/' && '/' <= hi {
+			ranges = [][2]rune{{lo, '/' - 1}, {'/' + 1, hi}}
+		}
+		for _, r := range ranges {
+			if r[0] > r[1] {
+				continue
+			}
+			fmt.Fprintf(&b, `\x{%x}`, r[0])
+			if r[1] > r[0] {
+				fmt.Fprintf(&b, `-\x{%x}`, r[1])
+			}
+			empty = false
+		}
+	}
+	if empty && !strings.HasPrefix(b.String(), "[^") {
+		return neverMatch, i, true
+	}
+	b.WriteByte(']')
+	return b.String(), i, true
+}
+
+// CompileRegexp combines the patterns, given in the order of increasing priority as for
+// NewMatcher, into a single regular expression over slash separated paths written with a
+// trailing slash for directories, see Pattern.Regexp. Every pattern is a named group,
+// "exclude_N" or "include_N" with N the index of the pattern, and the groups are ordered
+// so that the deciding pattern is the group matched, see RegexpResult. With the Lenient
+// option the regular expression decides on its own. Otherwise it only matches the path
+// itself and, as git does, a path is excluded when any of its parent directories is
+// excluded: callers check "a/", "a/b/" and so on before "a/b/c". Patterns implemented
+// outside of this package are left out.
+func CompileRegexp(patterns []Pattern, options MatcherOptions) (*regexp.Regexp, error) {
+	var b strings.Builder
+	if options.CaseInsensitive {
+		b.WriteString("(?i)")
+	}
+	b.WriteString("(?:")
+	alternatives := 0
+	for i := len(patterns) - 1; i >= 0; i-- {
+		p, ok := patterns[i].(*ptrn)
+		if !ok {
+			continue
+		}
+		if alternatives++; alternatives > 1 {
+			b.WriteByte('|')
+		}
+		name := "exclude_"
+		if p.inclusion {
+			name = "include_"
+		}
+		b.WriteString("(?P<" + name + strconv.Itoa(i) + ">" + p.regexpSource(!options.Lenient) + ")")
+	}
+	if alternatives == 0 {
+		b.WriteString(neverMatch)
+	}
+	b.WriteByte(')')
+	return regexp.Compile(b.String())
+}
+
+// RegexpResult matches the path against a regular expression compiled by CompileRegexp
+// and returns the outcome along with the index of the deciding pattern, -1 if none.
+func RegexpResult(re *regexp.Regexp, path string) (MatchResult, int) {
+	match := re.FindStringSubmatchIndex(path)
+	if match == nil {
+		return NoMatch, -1
+	}
+	for g, name := range re.SubexpNames() {
+		if g == 0 || match[2*g] < 0 {
+			continue
+		}
+		result := Exclude
+		if index, ok := strings.CutPrefix(name, "include_"); ok {
+			result, name = Include, index
+		} else {
+			name = strings.TrimPrefix(name, "exclude_")
+		}
+		if i, err := strconv.Atoi(name); err == nil {
+			return result, i
+		}
+	}
+	return NoMatch, -1
+}
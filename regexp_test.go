@@ -0,0 +1,113 @@
+// Copyright (c) 2017. Oleg Sklyar & teris.io. All rights reserved.
+// See the LICENSE file in the project root for licensing information.
+
+package gitignore_test
+
+import (
+	"github.com/teris-io/gitignore"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func regexpPath(path []string, isDir bool) string {
+	s := strings.Join(path, "/")
+	if isDir {
+		s += "/"
+	}
+	return s
+}
+
+var regexpPatterns = []string{
+	"v[!o]l[[:alpha:]]ano", "[]a]*", "[z-a]x", "[^a-c]", "a?c", "\\[x\\]", "a.b", "x/**",
+	"/**", "**/", "a/**/**/b", "a/b**", "[[:upper:]]*", "café", "Kelvin", "*/",
+	"a\\**", "[a-\\]]", "a/[", "[[:foo:]]", "bad\\", "a/***/x", "x/b**y/c", "x/**/**", "/***", "[a/b]", "x[a/b]y", "x[+-0]y", "x[!a/b]y",
+}
+
+func TestPattern_Regexp(t *testing.T) {
+	_, paths := compiledCases(7)
+	paths = append(paths, [][]string{
+		{"vxlzano"}, {"volcano"}, {"]"}, {"abc"}, {"a.b"}, {"axb"}, {"[x]"}, {"x"}, {"x", "y"},
+		{"CAFÉ"}, {"kelvin"}, {"a*z"}, {"a", "bc"}, {"d"}, {"\\"}, {"a", "x"}, {"a", "b", "x"},
+		{"x", "by", "c"}, {"x", "b", "y", "c"}, {"x+y"}, {"x.y"}, {"x0y"}, {"xay"}, {"x", "y"}, {"x", "a"},
+	}...)
+	texts := append(append([]string(nil), compiledPatterns...), regexpPatterns...)
+	for _, fold := range []bool{false, true} {
+		for _, text := range texts {
+			for _, domain := range [][]string{nil, {"a"}} {
+				p := gitignore.ParsePatternWithOptions(text, domain, gitignore.ParseOptions{CaseInsensitive: fold})
+				re := p.Regexp()
+				for _, path := range paths {
+					for _, isDir := range []bool{false, true} {
+						expected := p.Match(path, isDir) != gitignore.NoMatch
+						if found := re.MatchString(regexpPath(path, isDir)); found != expected {
+							t.Errorf("%q fold %v domain %v, %q: expected %v, found %v (%s)", text, fold, domain, regexpPath(path, isDir), expected, found, re)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestPattern_Regexp_compiledOnce(t *testing.T) {
+	p := gitignore.ParsePattern("a/**", nil)
+	if re1, re2 := p.Regexp(), p.Regexp(); re1 != re2 {
+		t.Errorf("expected the same expression, found %p and %p", re1, re2)
+	}
+}
+
+func TestCompileRegexp(t *testing.T) {
+	for _, options := range []gitignore.MatcherOptions{{}, {Lenient: true}, {CaseInsensitive: true}} {
+		for seed := int64(0); seed < 5; seed++ {
+			patterns, paths := compiledCases(seed)
+			m := gitignore.NewMatcherWithOptions(patterns, options)
+			re, err := gitignore.CompileRegexp(patterns, options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, path := range paths[:500] {
+				for _, isDir := range []bool{false, true} {
+					e := m.Explain(path, isDir)
+					result, index := regexpExplain(re, path, isDir, options.Lenient)
+					if result != e.Result || result != gitignore.NoMatch && patterns[index] != e.Pattern {
+						t.Fatalf("%+v seed %d, %q: expected %v by %v, found %v by %d", options, seed, regexpPath(path, isDir), e.Result, e.Pattern, result, index)
+					}
+				}
+			}
+		}
+	}
+}
+
+// regexpExplain matches the path against a combined regexp checking the parent
+// directories first unless lenient.
+func regexpExplain(re *regexp.Regexp, path []string, isDir, lenient bool) (gitignore.MatchResult, int) {
+	if !lenient {
+		for i := 1; i < len(path); i++ {
+			if result, index := gitignore.RegexpResult(re, regexpPath(path[:i], true)); result == gitignore.Exclude {
+				return result, index
+			}
+		}
+	}
+	return gitignore.RegexpResult(re, regexpPath(path, isDir))
+}
+
+func TestCompileRegexp_groups(t *testing.T) {
+	patterns := []gitignore.Pattern{
+		gitignore.ParsePattern("*.log", nil),
+		gitignore.ParsePattern("!keep.log", nil),
+	}
+	re, err := gitignore.CompileRegexp(patterns, gitignore.MatcherOptions{Lenient: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := re.SubexpNames(); len(names) != 3 || names[1] != "include_1" || names[2] != "exclude_0" {
+		t.Errorf("expected named groups per pattern, found %v", names)
+	}
+	if result, index := gitignore.RegexpResult(re, "a/keep.log"); result != gitignore.Include || index != 1 {
+		t.Errorf("expected Include by 1, found %v by %d", result, index)
+	}
+	if result, index := gitignore.RegexpResult(re, "main.go"); result != gitignore.NoMatch || index != -1 {
+		t.Errorf("expected NoMatch, found %v by %d", result, index)
+	}
+}
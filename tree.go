@@ -0,0 +1,244 @@
+// Copyright (c) 2017. Oleg Sklyar & teris.io. All rights reserved.
+// See the LICENSE file in the project root for licensing information.
+
+package gitignore
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// TreeLoader loads the patterns of the directory at the given path relative to the root
+// of the tree, typically those of its .gitignore file with the path as their domain.
+type TreeLoader func(dir []string) ([]Pattern, error)
+
+// NewOSTreeLoader constructs a loader reading the .gitignore files of the file system
+// tree rooted at root. A directory without a .gitignore file has no patterns.
+func NewOSTreeLoader(root string) TreeLoader {
+	return func(dir []string) ([]Pattern, error) {
+		data, err := (&osDir{root: root, path: dir}).ReadFile(".gitignore")
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		return parsePatterns(data, dir, Origin{Source: gitignoreSource(dir), Layer: GitignoreLayer}), nil
+	}
+}
+
+// TreeMatcher matches paths against the patterns of a tree of directories, loading the
+// patterns of a directory on first use. Matching a path only visits the directories on
+// the way to it, and not those under an excluded directory as git does not look into
+// them, so the cost does not depend on the number of patterns elsewhere in the tree.
+// Directories can be loaded and evicted independently, and concurrently with matching.
+type TreeMatcher struct {
+	mu      sync.RWMutex
+	options MatcherOptions
+	loader  TreeLoader
+	root    *treeNode
+	// foreign tells whether any pattern loaded is implemented outside of this package
+	foreign atomic.Bool
+}
+
+type treeNode struct {
+	loaded   bool
+	patterns []Pattern
+	err      error
+	children map[string]*treeNode
+}
+
+// NewTreeMatcher constructs a new tree matcher loading patterns with the loader.
+func NewTreeMatcher(loader TreeLoader) *TreeMatcher {
+	return NewTreeMatcherWithOptions(loader, MatcherOptions{})
+}
+
+// NewTreeMatcherWithOptions constructs a new tree matcher with the given options.
+func NewTreeMatcherWithOptions(loader TreeLoader, options MatcherOptions) *TreeMatcher {
+	return &TreeMatcher{options: options, loader: loader, root: &treeNode{}}
+}
+
+// Match matches the path against the patterns of the directories on the way to it.
+func (m *TreeMatcher) Match(path []string, isDir bool) bool {
+	return m.Result(path, isDir) == Exclude
+}
+
+// MatchPath matches a path given as a string against the patterns of the directories on
+// the way to it.
+func (m *TreeMatcher) MatchPath(path string, isDir bool) (bool, error) {
+	return matchPath(m, path, isDir)
+}
+
+// MatchFile matches a file of the file system tree rooted at root against the patterns
+// of the directories on the way to it.
+func (m *TreeMatcher) MatchFile(root, path string) (bool, error) {
+	return matchFile(m, root, path)
+}
+
+// MatchString matches a clean slash separated path against the patterns of the
+// directories on the way to it without allocating, see Matcher.
+func (m *TreeMatcher) MatchString(path string, isDir bool) bool {
+	var buf [maxStackDepth]string
+	split := splitString(buf[:0], path)
+	return m.explain(split, heapPath(split, m.foreign.Load()), isDir).Result == Exclude
+}
+
+// MatchBytes matches a path as MatchString does without copying the bytes.
+func (m *TreeMatcher) MatchBytes(path []byte, isDir bool) bool {
+	return m.MatchString(bytesToString(path), isDir)
+}
+
+// Result matches the path against the patterns of the directories on the way to it and
+// returns the outcome.
+func (m *TreeMatcher) Result(path []string, isDir bool) MatchResult {
+	return m.Explain(path, isDir).Result
+}
+
+// Explain matches the path against the patterns of the directories on the way to it and
+// reports the pattern that decided the outcome. A directory that failed to load is
+// matched as one without patterns until it is evicted or loaded again.
+func (m *TreeMatcher) Explain(path []string, isDir bool) Explanation {
+	return m.explain(path, path, isDir)
+}
+
+// explain matches the path as Explain does, see matcher.explain.
+func (m *TreeMatcher) explain(path, heap []string, isDir bool) Explanation {
+	var flags matchFlags
+	if m.options.CaseInsensitive {
+		flags |= matchFold
+	}
+	var buf [maxStackDepth][]Pattern
+	chain := buf[:0]
+	for i := 1; i < len(path); i++ {
+		chain = append(chain, m.dirPatterns(path[:i-1]))
+		if m.options.Lenient {
+			continue
+		}
+		if pattern, match := matchChain(chain, path[:i], heap, true, flags|matchExact); match == Exclude {
+			return explain(pattern, match, path[:i])
+		}
+	}
+	if len(path) > 0 {
+		chain = append(chain, m.dirPatterns(path[:len(path)-1]))
+	}
+	if !m.options.Lenient {
+		flags |= matchExact
+	}
+	if pattern, match := matchChain(chain, path, heap, isDir, flags); match > NoMatch {
+		return explain(pattern, match, nil)
+	}
+	return Explanation{}
+}
+
+// matchChain matches the path against the patterns of the directories on the way to it,
+// deeper directories and later patterns first, and returns the first outcome found.
+func matchChain(chain [][]Pattern, path, heap []string, isDir bool, flags matchFlags) (Pattern, MatchResult) {
+	for i := len(chain) - 1; i >= 0; i-- {
+		patterns := chain[i]
+		for j := len(patterns) - 1; j >= 0; j-- {
+			if match := matchPattern(patterns[j], path, heap, isDir, flags); match > NoMatch {
+				return patterns[j], match
+			}
+		}
+	}
+	return nil, NoMatch
+}
+
+// Load loads the patterns of the directory anew, replacing those loaded before, and
+// returns the error of the loader if any. The directories under it are kept.
+func (m *TreeMatcher) Load(dir []string) error {
+	patterns, err := m.loader(dir)
+	if hasForeign(patterns) {
+		m.foreign.Store(true)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	node := m.root.insert(dir)
+	node.loaded, node.patterns, node.err = true, patterns, err
+	return err
+}
+
+// Evict drops the patterns of the directory and of all directories under it. They are
+// loaded again on next use.
+func (m *TreeMatcher) Evict(dir []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(dir) == 0 {
+		m.root = &treeNode{}
+		return
+	}
+	if parent := m.root.find(dir[:len(dir)-1]); parent != nil {
+		delete(parent.children, dir[len(dir)-1])
+	}
+}
+
+// Err returns the error of the last attempt to load the directory, nil if it loaded
+// fine or has not been loaded.
+func (m *TreeMatcher) Err(dir []string) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if node := m.root.find(dir); node != nil {
+		return node.err
+	}
+	return nil
+}
+
+// dirPatterns returns the patterns of the directory loading them if necessary.
+func (m *TreeMatcher) dirPatterns(dir []string) []Pattern {
+	m.mu.RLock()
+	node := m.root.find(dir)
+	if node != nil && node.loaded {
+		defer m.mu.RUnlock()
+		return node.patterns
+	}
+	m.mu.RUnlock()
+
+	// the path may be split from a string that is not to be retained
+	clone := cloneNames(dir)
+	patterns, err := m.loader(clone)
+	if hasForeign(patterns) {
+		m.foreign.Store(true)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	node = m.root.insert(clone)
+	if !node.loaded {
+		// unless loaded concurrently in the meantime
+		node.loaded, node.patterns, node.err = true, patterns, err
+	}
+	return node.patterns
+}
+
+func cloneNames(names []string) []string {
+	clone := make([]string, len(names))
+	for i, name := range names {
+		clone[i] = strings.Clone(name)
+	}
+	return clone
+}
+
+func (n *treeNode) find(dir []string) *treeNode {
+	for _, name := range dir {
+		if n = n.children[name]; n == nil {
+			return nil
+		}
+	}
+	return n
+}
+
+func (n *treeNode) insert(dir []string) *treeNode {
+	for _, name := range dir {
+		if n.children == nil {
+			n.children = make(map[string]*treeNode)
+		}
+		c, ok := n.children[name]
+		if !ok {
+			c = &treeNode{}
+			n.children[name] = c
+		}
+		n = c
+	}
+	return n
+}
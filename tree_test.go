@@ -0,0 +1,181 @@
+// Copyright (c) 2017. Oleg Sklyar & teris.io. All rights reserved.
+// See the LICENSE file in the project root for licensing information.
+
+package gitignore_test
+
+import (
+	"errors"
+	"github.com/teris-io/gitignore"
+	"strings"
+	"testing"
+)
+
+// countingLoader serves patterns from a map keyed by the slash joined directory and
+// counts the loads per directory.
+type countingLoader struct {
+	files map[string]string
+	loads map[string]int
+	err   map[string]error
+}
+
+func (l *countingLoader) load(dir []string) ([]gitignore.Pattern, error) {
+	key := strings.Join(dir, "/")
+	l.loads[key]++
+	if err := l.err[key]; err != nil {
+		return nil, err
+	}
+	var patterns []gitignore.Pattern
+	for _, line := range strings.Split(l.files[key], "\n") {
+		if line != "" {
+			patterns = append(patterns, gitignore.ParsePattern(line, dir))
+		}
+	}
+	return patterns, nil
+}
+
+func newCountingLoader() *countingLoader {
+	return &countingLoader{
+		files: map[string]string{
+			"":      "*.log\nbuild/",
+			"a":     "!keep.log\nsecret",
+			"a/b":   "*.txt",
+			"build": "!*",
+			"other": "*",
+		},
+		loads: make(map[string]int),
+		err:   make(map[string]error),
+	}
+}
+
+func TestTreeMatcher(t *testing.T) {
+	l := newCountingLoader()
+	m := gitignore.NewTreeMatcher(l.load)
+	cases := []struct {
+		path     string
+		isDir    bool
+		expected gitignore.MatchResult
+	}{
+		{"x.log", false, gitignore.Exclude},
+		{"a/keep.log", false, gitignore.Include},
+		{"a/b/keep.log", false, gitignore.Include},
+		{"a/b/c.txt", false, gitignore.Exclude},
+		{"a/secret", true, gitignore.Exclude},
+		{"a/secret/b/keep.log", false, gitignore.Exclude},
+		{"build/x", false, gitignore.Exclude},
+		{"main.go", false, gitignore.NoMatch},
+	}
+	for _, tc := range cases {
+		if res := m.Result(strings.Split(tc.path, "/"), tc.isDir); res != tc.expected {
+			t.Errorf("%s: expected %v, found %v", tc.path, tc.expected, res)
+		}
+	}
+	for _, dir := range []string{"build", "a/secret", "other"} {
+		if l.loads[dir] != 0 {
+			t.Errorf("expected %s not to be loaded, found %d loads", dir, l.loads[dir])
+		}
+	}
+	for dir, n := range l.loads {
+		if n != 1 {
+			t.Errorf("expected %q to be loaded once, found %d", dir, n)
+		}
+	}
+}
+
+func TestTreeMatcher_matchesFlatMatcher(t *testing.T) {
+	l := newCountingLoader()
+	var all []gitignore.Pattern
+	parsed := make(map[string][]gitignore.Pattern)
+	for _, dir := range []string{"", "a", "a/b", "build", "other"} {
+		var path []string
+		if dir != "" {
+			path = strings.Split(dir, "/")
+		}
+		parsed[dir], _ = l.load(path)
+		all = append(all, parsed[dir]...)
+	}
+	loader := func(dir []string) ([]gitignore.Pattern, error) {
+		return parsed[strings.Join(dir, "/")], nil
+	}
+	_, paths := compiledCases(3)
+	for _, options := range []gitignore.MatcherOptions{{}, {Lenient: true}} {
+		flat := gitignore.NewMatcherWithOptions(all, options)
+		tree := gitignore.NewTreeMatcherWithOptions(loader, options)
+		for _, path := range paths {
+			for _, isDir := range []bool{false, true} {
+				if expected, found := flat.Explain(path, isDir), tree.Explain(path, isDir); expected.Pattern != found.Pattern || expected.Result != found.Result {
+					t.Fatalf("%+v %v: expected %+v, found %+v", options, path, expected, found)
+				}
+			}
+		}
+	}
+}
+
+func TestTreeMatcher_LoadEvict(t *testing.T) {
+	l := newCountingLoader()
+	m := gitignore.NewTreeMatcher(l.load)
+	path := []string{"a", "b", "c.txt"}
+	if !m.Match(path, false) {
+		t.Error("expected a/b/c.txt to be excluded")
+	}
+
+	l.files["a/b"] = ""
+	if !m.Match(path, false) {
+		t.Error("expected the patterns of a/b to stay loaded")
+	}
+	m.Evict([]string{"a"})
+	if m.Match(path, false) {
+		t.Error("expected a/b to be loaded anew")
+	}
+	if l.loads["a"] != 2 || l.loads["a/b"] != 2 || l.loads[""] != 1 {
+		t.Errorf("expected only a and a/b to be loaded again, found %v", l.loads)
+	}
+
+	l.files["a/b"] = "c.*"
+	if err := m.Load([]string{"a", "b"}); err != nil {
+		t.Errorf("expected no error, found %v", err)
+	}
+	if !m.Match(path, false) {
+		t.Error("expected the reloaded patterns of a/b")
+	}
+}
+
+func TestTreeMatcher_loadError(t *testing.T) {
+	l := newCountingLoader()
+	failure := errors.New("failure")
+	l.err["a"] = failure
+	m := gitignore.NewTreeMatcher(l.load)
+	if res := m.Result([]string{"a", "keep.log"}, false); res != gitignore.Exclude {
+		t.Errorf("expected Exclude, found %v", res)
+	}
+	if err := m.Err([]string{"a"}); err != failure {
+		t.Errorf("expected %v, found %v", failure, err)
+	}
+	if err := m.Load([]string{"a"}); err != failure {
+		t.Errorf("expected %v, found %v", failure, err)
+	}
+	delete(l.err, "a")
+	if err := m.Load([]string{"a"}); err != nil || m.Err([]string{"a"}) != nil {
+		t.Errorf("expected no error, found %v", err)
+	}
+	if res := m.Result([]string{"a", "keep.log"}, false); res != gitignore.Include {
+		t.Errorf("expected Include, found %v", res)
+	}
+}
+
+func TestNewOSTreeLoader(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, ".gitignore", "*.log\n")
+	writeFile(t, root, "a/.gitignore", "!keep.log\r\n")
+	writeFile(t, root, "a/keep.log", "")
+	m := gitignore.NewTreeMatcher(gitignore.NewOSTreeLoader(root))
+	if !m.Match([]string{"b", "x.log"}, false) {
+		t.Error("expected b/x.log to be excluded")
+	}
+	e := m.Explain([]string{"a", "keep.log"}, false)
+	if e.Result != gitignore.Include || e.Source != "a/.gitignore" || e.Line != 1 {
+		t.Errorf("expected Include by a/.gitignore:1, found %+v", e)
+	}
+	if err := m.Err([]string{"b"}); err != nil {
+		t.Errorf("expected a missing .gitignore not to fail, found %v", err)
+	}
+}
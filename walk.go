@@ -0,0 +1,119 @@
+// Copyright (c) 2017. Oleg Sklyar & teris.io. All rights reserved.
+// See the LICENSE file in the project root for licensing information.
+
+package gitignore
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// WalkFunc is the type of the function called by Walk for each file or directory. The
+// arguments have the meaning of those of fs.WalkDirFunc, and result is the outcome of
+// matching the entry against the gitignore patterns in effect for it. The root is
+// always reported with NoMatch.
+type WalkFunc func(path string, d fs.DirEntry, result MatchResult, err error) error
+
+// Walk walks the file tree rooted at root in lexical order calling fn for each file or
+// directory in the tree, including root, much like filepath.WalkDir does. The .gitignore
+// file of every directory is read when the directory is entered and applies to its
+// content. Excluded directories are reported to fn with the Exclude result, but are
+// neither read nor entered, as git does. Entries named .git are not reported and
+// sub-directories containing a .git directory or file, that is nested repositories,
+// submodules and worktrees, are reported but not entered. Returning filepath.SkipDir
+// or filepath.SkipAll from fn has the same effect as with filepath.WalkDir.
+func Walk(root string, fn WalkFunc) error {
+	info, err := os.Lstat(root)
+	if err != nil {
+		err = fn(root, nil, NoMatch, err)
+	} else {
+		w := &walker{root: root, fn: fn}
+		err = w.walkDir(root, nil, fs.FileInfoToDirEntry(info), NoMatch, nil)
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+type walker struct {
+	root string
+	fn   WalkFunc
+}
+
+func (w *walker) walkDir(name string, path []string, d fs.DirEntry, result MatchResult, parent *layer) error {
+	if err := w.fn(name, d, result, nil); err != nil || !d.IsDir() || result == Exclude {
+		if err == filepath.SkipDir && d.IsDir() {
+			err = nil
+		}
+		return err
+	}
+	if len(path) > 0 && isRepository(name) {
+		return nil
+	}
+
+	l := parent.load(&osDir{root: w.root, path: path})
+	entries, err := os.ReadDir(name)
+	if err != nil {
+		if err = w.fn(name, d, result, err); err != nil {
+			if err == filepath.SkipDir {
+				err = nil
+			}
+			return err
+		}
+	}
+	for _, entry := range entries {
+		if entry.Name() == ".git" {
+			continue
+		}
+		entryPath := append(path[:len(path):len(path)], entry.Name())
+		entryResult := l.match(entryPath, entry.IsDir())
+		if err := w.walkDir(filepath.Join(name, entry.Name()), entryPath, entry, entryResult, l); err != nil {
+			if err == filepath.SkipDir {
+				break
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// isRepository reports whether the directory contains a .git directory or file.
+func isRepository(name string) bool {
+	_, err := os.Lstat(filepath.Join(name, ".git"))
+	return err == nil
+}
+
+// layer holds the patterns of a single gitignore file and links to the layer of the
+// closest parent directory with patterns, so that a directory inherits the patterns of
+// its parents without copying them.
+type layer struct {
+	parent   *layer
+	patterns []Pattern
+}
+
+// load returns a new layer with the patterns of the .gitignore file in the directory,
+// or the receiver if there are none.
+func (l *layer) load(dir Dir) *layer {
+	data, err := dir.ReadFile(".gitignore")
+	if err != nil {
+		return l
+	}
+	origin := Origin{Source: gitignoreSource(dir.Path()), Layer: GitignoreLayer}
+	if patterns := parsePatterns(data, dir.Path(), origin); len(patterns) > 0 {
+		return &layer{parent: l, patterns: patterns}
+	}
+	return l
+}
+
+// match matches the path itself against the patterns of the layer and its parents
+// in the order of decreasing priority and returns the first outcome found.
+func (l *layer) match(path []string, isDir bool) MatchResult {
+	for ; l != nil; l = l.parent {
+		if _, res := matchLast(l.patterns, path, path, isDir, 0); res > NoMatch {
+			return res
+		}
+	}
+	return NoMatch
+}
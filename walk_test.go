@@ -0,0 +1,101 @@
+// Copyright (c) 2017. Oleg Sklyar & teris.io. All rights reserved.
+// See the LICENSE file in the project root for licensing information.
+
+package gitignore_test
+
+import (
+	"github.com/teris-io/gitignore"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func walkTree(t testing.TB) string {
+	root := t.TempDir()
+	writeFile(t, root, ".gitignore", "*.log\nnode_modules/\n/build/*\n!/build/keep/\n")
+	writeFile(t, root, "main.go", "")
+	writeFile(t, root, "debug.log", "")
+	writeFile(t, root, "src/.gitignore", "!keep.log\n")
+	writeFile(t, root, "src/keep.log", "")
+	writeFile(t, root, "src/drop.log", "")
+	writeFile(t, root, "node_modules/.gitignore", "!*\n")
+	writeFile(t, root, "node_modules/lib/index.js", "")
+	writeFile(t, root, "build/out.o", "")
+	writeFile(t, root, "build/keep/out.o", "")
+	writeFile(t, root, ".git/HEAD", "")
+	writeFile(t, root, "module/.git", "")
+	writeFile(t, root, "module/file.txt", "")
+	return root
+}
+
+func walkResults(t *testing.T, root string, fn func(path string, d fs.DirEntry) error) map[string]gitignore.MatchResult {
+	results := make(map[string]gitignore.MatchResult)
+	err := gitignore.Walk(root, func(path string, d fs.DirEntry, result gitignore.MatchResult, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		results[filepath.ToSlash(rel)] = result
+		if fn != nil {
+			return fn(path, d)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("no error expected, found %v", err)
+	}
+	return results
+}
+
+func TestWalk(t *testing.T) {
+	root := walkTree(t)
+	results := walkResults(t, root, nil)
+	expected := map[string]gitignore.MatchResult{
+		".":                gitignore.NoMatch,
+		".gitignore":       gitignore.NoMatch,
+		"main.go":          gitignore.NoMatch,
+		"debug.log":        gitignore.Exclude,
+		"src":              gitignore.NoMatch,
+		"src/.gitignore":   gitignore.NoMatch,
+		"src/keep.log":     gitignore.Include,
+		"src/drop.log":     gitignore.Exclude,
+		"node_modules":     gitignore.Exclude,
+		"build":            gitignore.NoMatch,
+		"build/out.o":      gitignore.Exclude,
+		"build/keep":       gitignore.Include,
+		"build/keep/out.o": gitignore.NoMatch,
+		"module":           gitignore.NoMatch,
+	}
+	if !reflect.DeepEqual(results, expected) {
+		t.Errorf("expected %v, found %v", expected, results)
+	}
+}
+
+func TestWalk_skipDir(t *testing.T) {
+	root := walkTree(t)
+	results := walkResults(t, root, func(path string, d fs.DirEntry) error {
+		if d.IsDir() && d.Name() == "src" {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if _, ok := results["src/keep.log"]; ok {
+		t.Errorf("expected src to be skipped")
+	}
+	if _, ok := results["main.go"]; !ok {
+		t.Errorf("expected main.go to be walked")
+	}
+}
+
+func TestWalk_missingRoot(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "missing")
+	var walkErr error
+	err := gitignore.Walk(root, func(path string, d fs.DirEntry, result gitignore.MatchResult, err error) error {
+		walkErr = err
+		return err
+	})
+	if err == nil || walkErr == nil {
+		t.Errorf("expected an error")
+	}
+}